@@ -1,21 +1,121 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/charkpep/yami/src/eval"
+	"github.com/charkpep/yami/src/object"
 	"github.com/charkpep/yami/src/parser"
 	"github.com/charkpep/yami/src/repl"
+	"github.com/charkpep/yami/src/resolver"
+	"github.com/charkpep/yami/src/types"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 )
 
+var (
+	guards     = flag.Bool("guards", false, "check arguments and results of annotated functions at runtime")
+	allowRead  = flag.String("allow-read", "", "allow reading files under the `directory`")
+	allowWrite = flag.String("allow-write", "", "allow writing files under the `directory`")
+	allowEnv   = flag.Bool("allow-env", false, "allow reading environment variables")
+	allowStdin = flag.Bool("allow-stdin", false, "allow reading the standard input")
+	allowAll   = flag.Bool("allow-all", false, "allow all resources, files are not restricted to directories")
+)
+
 func main() {
-	if len(os.Args) == 1 {
-		r := repl.New(os.Stdin, os.Stdout)
-		r.Start()
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: monkey [flags] [file [args...]]\n       monkey check file\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+	if flag.NArg() == 0 {
+		r := repl.New(os.Stdin, os.Stdout, options()...)
+		os.Exit(r.Start())
+	}
+
+	if flag.Arg(0) == "check" && flag.NArg() == 2 {
+		check(flag.Arg(1))
+		return
+	}
+
+	root := parse(flag.Arg(0))
+	diagnostics := resolver.New().Resolve(root).Diagnostics
+	report(diagnostics)
+	if resolver.HasErrors(diagnostics) {
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	// arguments after the file name are the arguments of the program
+	e := eval.NewEvaluator(append(options(), eval.WithArgs(flag.Args()[1:]...))...)
+	_, err := e.EvalContext(ctx, root)
+	var exit *object.ExitError
+	if errors.As(err, &exit) {
+		os.Exit(exit.Code)
+	}
+
+	if err != nil {
+		io.WriteString(os.Stdout, err.Error())
+		io.WriteString(os.Stdout, "\n")
+		os.Exit(1)
+	}
+}
+
+// options configures the evaluator by the flags, programs get object.DefaultCapabilities and the resources
+// allowed by the flags
+func options() []eval.Option {
+	caps := object.Capabilities{Granted: object.DefaultCapabilities}
+	if *allowAll {
+		caps.Granted = object.AllCapabilities
+	}
+
+	if *allowRead != "" {
+		caps.Granted |= object.FileRead
+		caps.ReadRoot = *allowRead
+	}
+
+	if *allowWrite != "" {
+		caps.Granted |= object.FileWrite
+		caps.WriteRoot = *allowWrite
 	}
 
-	f := os.Args[1]
+	if *allowEnv {
+		caps.Granted |= object.EnvRead
+	}
+
+	if *allowStdin {
+		caps.Granted |= object.Stdin
+	}
+
+	opts := []eval.Option{eval.WithCapabilities(caps)}
+	if *guards {
+		opts = append(opts, eval.WithTypeGuards())
+	}
+
+	return opts
+}
+
+// check reports diagnostics of the resolver and the type checker without running the program
+func check(f string) {
+	root := parse(f)
+	diagnostics := resolver.New().Resolve(root).Diagnostics
+	if !resolver.HasErrors(diagnostics) {
+		diagnostics = append(diagnostics, types.Check(root)...)
+	}
+
+	report(diagnostics)
+	if resolver.HasErrors(diagnostics) {
+		os.Exit(1)
+	}
+}
+
+func parse(f string) parser.Node {
 	p, err := filepath.Abs(f)
 	if err != nil {
 		io.WriteString(os.Stdout, err.Error())
@@ -23,6 +123,13 @@ func main() {
 	}
 
 	fd, err := os.OpenFile(p, os.O_RDONLY, 770)
+	if err != nil {
+		io.WriteString(os.Stdout, err.Error())
+		io.WriteString(os.Stdout, "\n")
+		os.Exit(1)
+	}
+
+	defer fd.Close()
 	parser := parser.NewParser(fd)
 	root, err := parser.Parse()
 	if err != nil {
@@ -39,12 +146,12 @@ func main() {
 		os.Exit(1)
 	}
 
-	e := eval.NewEvaluator()
-	if _, err := e.Eval(root); err != nil {
-		io.WriteString(os.Stdout, err.Error())
+	return root
+}
+
+func report(diagnostics []resolver.Diagnostic) {
+	for _, d := range diagnostics {
+		io.WriteString(os.Stdout, d.Error())
 		io.WriteString(os.Stdout, "\n")
-		os.Exit(1)
-	} else {
-		//fmt.Println(val)
 	}
 }
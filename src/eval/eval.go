@@ -1,10 +1,17 @@
 package eval
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/charkpep/yami/src/lexer"
 	"github.com/charkpep/yami/src/object"
 	"github.com/charkpep/yami/src/parser"
-	"strings"
+	"github.com/charkpep/yami/src/types"
+	"io"
+	"math"
+	"math/big"
+	"unicode/utf8"
 )
 
 type RuntimeError struct {
@@ -27,31 +34,279 @@ func NewRuntimeError(msg string, obj parser.Node) RuntimeError {
 	}
 }
 
-type Evaluator struct{}
+// CanceledError the evaluation was stopped because the context is done, Err is the error of the context and
+// the node is the call or the loop where the evaluation stopped
+type CanceledError struct {
+	RuntimeError
+	Err error
+}
+
+func (ce CanceledError) Unwrap() error {
+	return ce.Err
+}
+
+func NewCanceledError(err error, node parser.Node) CanceledError {
+	return CanceledError{
+		RuntimeError: NewRuntimeError("evaluation is canceled: "+err.Error(), node),
+		Err:          err,
+	}
+}
+
+// LimitError the evaluation exceeded one of the limits set by the options, Err tells which one and the node is
+// where the limit was exceeded
+type LimitError struct {
+	RuntimeError
+	Err *object.LimitError
+}
+
+func (le LimitError) Unwrap() error {
+	return le.Err
+}
+
+func NewLimitError(err *object.LimitError, node parser.Node) LimitError {
+	return LimitError{
+		RuntimeError: NewRuntimeError(err.Error(), node),
+		Err:          err,
+	}
+}
+
+// PermissionError the build in function needs the capability which is not granted by WithCapabilities, Err tells
+// which one and the node is the call of the function
+type PermissionError struct {
+	RuntimeError
+	Err *object.PermissionError
+}
+
+func (pe PermissionError) Unwrap() error {
+	return pe.Err
+}
+
+func NewPermissionError(err *object.PermissionError, node parser.Node) PermissionError {
+	return PermissionError{
+		RuntimeError: NewRuntimeError(err.Error(), node),
+		Err:          err,
+	}
+}
+
+type Evaluator struct {
+	typeGuards bool
+	// gen generator running the evaluated code, nil outside generators
+	gen *generator
+	// generators created by Eval, nil for EvalWithEnv as the environment outlives the call
+	generators *generators
+	// ctx stops the evaluation, Eval cancels it when it returns, so tasks left waiting for channels or other tasks
+	// stop as well
+	ctx    context.Context
+	limits object.Limits
+	// budget counts the resources used by the evaluation, it is shared by its tasks
+	budget *object.Budget
+	// depth of the calls evaluated by the goroutine
+	depth        int
+	capabilities object.Capabilities
+	stdin        *object.Input
+	args         []string
+}
+
+// DefaultMaxCallDepth limits the depth of nested calls unless WithMaxCallDepth sets its own, runaway recursion
+// fails with LimitError long before it overflows the stack of the goroutine
+const DefaultMaxCallDepth = 10000
+
+type Option func(e *Evaluator)
+
+// WithTypeGuards checks arguments and results of the functions against the type annotations at runtime
+func WithTypeGuards() Option {
+	return func(e *Evaluator) {
+		e.typeGuards = true
+	}
+}
+
+// WithMaxSteps stops the evaluation after n evaluated nodes, e.g. scripts running for too long
+func WithMaxSteps(n int64) Option {
+	return func(e *Evaluator) {
+		e.limits.MaxSteps = n
+	}
+}
+
+// WithMaxCallDepth limits the depth of nested calls, deep recursion fails instead of overflowing the stack.
+// DefaultMaxCallDepth is used without the option, zero does not limit the depth.
+func WithMaxCallDepth(n int) Option {
+	return func(e *Evaluator) {
+		e.limits.MaxCallDepth = n
+	}
+}
+
+// WithMaxStringLen limits the length of the strings created by the evaluation
+func WithMaxStringLen(n int) Option {
+	return func(e *Evaluator) {
+		e.limits.MaxStringLen = n
+	}
+}
+
+// WithMaxArrayLen limits the length of the arrays created by the evaluation
+func WithMaxArrayLen(n int) Option {
+	return func(e *Evaluator) {
+		e.limits.MaxArrayLen = n
+	}
+}
+
+// WithMaxMapLen limits the number of entries of the maps created by the evaluation
+func WithMaxMapLen(n int) Option {
+	return func(e *Evaluator) {
+		e.limits.MaxMapLen = n
+	}
+}
+
+// WithMaxAlloc limits the approximate number of bytes allocated by strings, arrays and maps during the evaluation
+func WithMaxAlloc(bytes int64) Option {
+	return func(e *Evaluator) {
+		e.limits.MaxAlloc = bytes
+	}
+}
+
+// WithCapabilities sets the resources of the host the program can use, object.DefaultCapabilities are granted
+// without the option
+func WithCapabilities(c object.Capabilities) Option {
+	return func(e *Evaluator) {
+		e.capabilities = c
+	}
+}
 
-func NewEvaluator() *Evaluator {
-	return &Evaluator{}
+// WithStdin sets the input read by read_line and read_lines, the standard input of the process is read without
+// the option
+func WithStdin(r io.Reader) Option {
+	return func(e *Evaluator) {
+		e.stdin = object.NewInput(r)
+	}
+}
+
+// WithArgs sets the arguments of the program returned by args()
+func WithArgs(args ...string) Option {
+	return func(e *Evaluator) {
+		e.args = args
+	}
+}
+
+func NewEvaluator(opts ...Option) *Evaluator {
+	e := &Evaluator{
+		limits:       object.Limits{MaxCallDepth: DefaultMaxCallDepth},
+		capabilities: object.Capabilities{Granted: object.DefaultCapabilities},
+		stdin:        object.DefaultInput,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
 }
 
 func (e Evaluator) Eval(node parser.Node) (object.Object, error) {
+	return e.EvalContext(context.Background(), node)
+}
+
+// EvalContext evaluates the program until it ends or the context is done. The context is checked at every call
+// and iteration of loops, waiting for channels and tasks is interrupted as well, the evaluation then fails
+// with CanceledError.
+func (e Evaluator) EvalContext(ctx context.Context, node parser.Node) (object.Object, error) {
 	env := object.NewEnv()
+	e.generators = newGenerators()
+	defer e.generators.closeAll()
+	var cancel context.CancelFunc
+	e.ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	e.budget = object.NewBudget(e.limits)
 	return e.eval(node, env)
 }
 
 func (e Evaluator) EvalWithEnv(node parser.Node, env *object.Environment) (object.Object, error) {
+	return e.EvalWithEnvContext(context.Background(), node, env)
+}
+
+// EvalWithEnvContext evaluates the node in the environment until it ends or the context is done, tasks spawned
+// by the node are not stopped when it returns as they may be awaited by the nodes evaluated later. Limits apply
+// to every call separately.
+func (e Evaluator) EvalWithEnvContext(ctx context.Context, node parser.Node, env *object.Environment) (object.Object, error) {
+	e.ctx = ctx
+	e.budget = object.NewBudget(e.limits)
 	return e.eval(node, env)
 }
 
+// canceled returns CanceledError at the node if the context is done
+func (e Evaluator) canceled(node parser.Node) error {
+	select {
+	case <-e.ctx.Done():
+		return NewCanceledError(e.ctx.Err(), node)
+	default:
+		return nil
+	}
+}
+
+// lookup returns the value of the variable, variables resolved by the resolver are read by slot, the rest
+// by name
+func lookup(env *object.Environment, ident parser.IdentifierExpression) (object.Object, bool) {
+	if res, ok := ident.Resolved(); ok {
+		return env.GetSlot(res.Depth, res.Slot)
+	}
+
+	return env.Get(ident.Identifier.Literal)
+}
+
+func define(env *object.Environment, ident parser.IdentifierExpression, val object.Object) {
+	if res, ok := ident.Resolved(); ok {
+		env.DefineSlot(res.Slot, val)
+		return
+	}
+
+	env.Define(ident.Identifier.Literal, val)
+}
+
+func assign(env *object.Environment, ident parser.IdentifierExpression, val object.Object) error {
+	if res, ok := ident.Resolved(); ok {
+		return env.SetSlot(ident.Identifier.Literal, res.Depth, res.Slot, val)
+	}
+
+	return env.Set(ident.Identifier.Literal, val)
+}
+
+func makeConst(env *object.Environment, ident parser.IdentifierExpression) {
+	if res, ok := ident.Resolved(); ok {
+		env.MakeConstSlot(res.Slot)
+		return
+	}
+
+	env.MakeConst(ident.Identifier.Literal)
+}
+
+// limitError reports the limit exceeded at the node, other errors are returned as is
+func limitError(err error, node parser.Node) error {
+	var le *object.LimitError
+	if errors.As(err, &le) && !errors.As(err, &LimitError{}) {
+		return NewLimitError(le, node)
+	}
+
+	return err
+}
+
 // TODO decouple in separate functions shit pile of switch case
 func (e Evaluator) eval(node parser.Node, env *object.Environment) (object.Object, error) {
+	if err := e.budget.Step(); err != nil {
+		return nil, limitError(err, node)
+	}
+
 	switch v := node.(type) {
 	case *parser.RootNode:
 		return e.evalStatements(v.Statements, env)
 	case parser.ExpressionStatement:
 		return e.eval(v.Expr, env)
 	case parser.LetStatement:
-		if _, ok := env.Get(v.Identifier.Token().Literal); ok {
-			return nil, NewRuntimeError("identifier is already defined", v)
+		// let shadows bindings of the outer scopes, only the current scope is checked
+		defined := make(map[string]bool)
+		for _, ident := range parser.PatternIdentifiers(v.Identifier) {
+			if env.Declared(ident.Identifier.Literal) || defined[ident.Identifier.Literal] {
+				return nil, NewRuntimeError("identifier is already defined", ident)
+			}
+
+			defined[ident.Identifier.Literal] = true
 		}
 
 		val, err := e.eval(v.Expression, env)
@@ -59,77 +314,57 @@ func (e Evaluator) eval(node parser.Node, env *object.Environment) (object.Objec
 			return nil, err
 		}
 
-		env.Set(v.Identifier.Token().Literal, val)
+		if err := e.bindPattern(v.Identifier, val, env); err != nil {
+			return nil, err
+		}
+
+		if v.Const {
+			for _, ident := range parser.PatternIdentifiers(v.Identifier) {
+				makeConst(env, ident)
+			}
+		}
+
 		return val, nil
+	case parser.StructStatement:
+		if env.Declared(v.Name.Identifier.Literal) {
+			return nil, NewRuntimeError("identifier is already defined", v.Name)
+		}
+
+		st := &object.StructType{
+			Name:    v.Name.Identifier.Literal,
+			Fields:  v.Fields,
+			Methods: make(map[string]object.FuncObject, len(v.Methods)),
+		}
+
+		// methods are closures of the scope declaring the struct, like functions assigned by let
+		for _, method := range v.Methods {
+			fn := object.NewFuncObject(method.Func.Args, method.Func.Body, env)
+			fn.ReturnType = method.Func.ReturnType
+			fn.Generator = method.Func.Generator
+			st.Methods[method.Name.Identifier.Literal] = fn
+		}
+
+		define(env, v.Name, st)
+		makeConst(env, v.Name)
+		return st, nil
+	case parser.EnumStatement:
+		return e.evalEnumStatement(v, env)
 	case parser.NilExpression:
 		return object.NIL, nil
 	case parser.IntegerExpression:
 		return object.IntegerObject{
 			Val: v.Val,
 		}, nil
+	case parser.BigIntegerExpression:
+		return object.BigIntObject{
+			Val: v.Val,
+		}, nil
+	case parser.FloatExpression:
+		return object.FloatObject{
+			Val: v.Val,
+		}, nil
 	case parser.AssignExpression:
-		switch identifierExpression := v.Identifier.(type) {
-		case parser.IndexExpression:
-			ident, ok := identifierExpression.Of.(parser.IdentifierExpression)
-			if !ok {
-				return nil, NewRuntimeError("expected identifier for indexed assignment", identifierExpression)
-			}
-
-			structure, ok := env.Get(ident.Identifier.Literal)
-			if !ok {
-				return nil, NewRuntimeError("identifier is not defined", identifierExpression)
-			}
-
-			idx, err := e.eval(identifierExpression.Idx, env)
-			if err != nil {
-				return nil, err
-			}
-
-			val, err := e.eval(v.Val, env)
-			if err != nil {
-				return nil, err
-			}
-
-			switch {
-			case structure.Type() == object.MAP_OBJ:
-				structure.(object.MapObject).Val[idx] = val
-			case structure.Type() == object.ARRAY_OBJ && idx.Type() == object.INTEGER_OBJ:
-				if idx.(object.IntegerObject).Val >= int64(len(structure.(*object.ArrayObject).Val)) {
-					return nil, NewRuntimeError("index out of bounds", ident)
-				}
-				structure.(*object.ArrayObject).Val[idx.(object.IntegerObject).Val] = val
-			case structure.Type() == object.STRING_OBJ && idx.Type() == object.INTEGER_OBJ:
-				index := idx.(object.IntegerObject).Val
-				obj := structure.(object.StringObject)
-				str := strings.Split(structure.(object.StringObject).Val, "")
-				value := val.(object.StringObject).Val
-				if len(value) > 1 {
-					return nil, NewRuntimeError("assignment by index to the string must contains only one character", ident)
-				}
-
-				if index >= int64(len(strings.Split(value, ""))) {
-					return nil, NewRuntimeError("index out of bounds", ident)
-				}
-
-				str[idx.(object.IntegerObject).Val] = val.(object.StringObject).Val
-				obj.Val = strings.Join(str, "")
-				env.Set(ident.Identifier.Literal, obj)
-			default:
-				return nil, NewRuntimeError("unsupported assignment", node)
-			}
-
-			return val, nil
-		case parser.IdentifierExpression:
-			val, err := e.eval(v.Val, env)
-			if err != nil {
-				return nil, err
-			}
-
-			env.Set(identifierExpression.Token().Literal, val)
-			return val, nil
-		default:
-			return nil, NewRuntimeError("unsupported assignment", node)
-		}
+		return e.evalAssign(v, env)
 	case parser.IfExpression:
 		condition, err := e.eval(v.Condition, env)
 		if err != nil {
@@ -142,16 +377,34 @@ func (e Evaluator) eval(node parser.Node, env *object.Environment) (object.Objec
 		}
 
 		if cond.Val {
-			return e.evalBlockStatement(v.Consequence, env)
+			return e.evalBlockStatement(v.Consequence, object.DeriveEnv(env))
 		}
 
 		if v.Alternative != nil {
-			return e.evalBlockStatement(*v.Alternative, env)
+			return e.evalBlockStatement(*v.Alternative, object.DeriveEnv(env))
 		}
 
 		return object.NIL, nil
+	case parser.MatchExpression:
+		return e.evalMatch(v, env)
 	case parser.FuncExpression:
-		return object.NewFuncObject(v.Args, v.Body, object.DeriveEnv(env)), nil
+		fn := object.NewFuncObject(v.Args, v.Body, env)
+		fn.ReturnType = v.ReturnType
+		fn.Generator = v.Generator
+		return fn, nil
+	case parser.YieldExpression:
+		if e.gen == nil {
+			return nil, NewRuntimeError("yield outside generator", v)
+		}
+
+		val, err := e.eval(v.Val, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return object.NIL, e.gen.yield(val)
+	case parser.ForStatement:
+		return e.evalFor(v, env)
 	case parser.BlockStatement:
 		derivedEvn := object.DeriveEnv(env)
 		return e.evalBlockStatement(v, derivedEvn)
@@ -165,7 +418,7 @@ func (e Evaluator) eval(node parser.Node, env *object.Environment) (object.Objec
 			Val: returnObj,
 		}, nil
 	case parser.IdentifierExpression:
-		val, ok := env.Get(v.Token().Literal)
+		val, ok := lookup(env, v)
 		if ok {
 			return val, nil
 		}
@@ -175,6 +428,11 @@ func (e Evaluator) eval(node parser.Node, env *object.Environment) (object.Objec
 			return val, nil
 		}
 
+		val, ok = object.BuildInNamespaces[v.Token().Literal]
+		if ok {
+			return val, nil
+		}
+
 		return nil, NewRuntimeError("identifier is not defined", v)
 	case parser.PrefixExpression:
 		return e.evalPrefix(v, env)
@@ -185,12 +443,31 @@ func (e Evaluator) eval(node parser.Node, env *object.Environment) (object.Objec
 		return eval, err
 	case parser.CallExpression:
 		return e.evalCallExpression(v, env)
+	case parser.PipeExpression:
+		return e.evalPipe(v, env)
+	case parser.SpawnExpression:
+		return e.evalSpawn(v, env)
+	case parser.AwaitExpression:
+		return e.evalAwait(v, env)
+	case parser.SelectExpression:
+		return e.evalSelect(v, env)
 	case parser.StringExpression:
 		return object.StringObject{
 			Val: v.Val,
 		}, nil
 	case parser.IndexExpression:
 		return e.evalIndex(v, env)
+	case parser.SliceExpression:
+		return e.evalSlice(v, env)
+	case parser.MemberExpression:
+		of, err := e.eval(v.Of, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return e.member(v, of)
+	case parser.StructExpression:
+		return e.evalStructExpression(v, env)
 	case parser.ArrayExpression:
 		return e.evalArray(v, env)
 	case parser.HashMapExpression:
@@ -200,133 +477,1127 @@ func (e Evaluator) eval(node parser.Node, env *object.Environment) (object.Objec
 	}
 }
 
-func (e Evaluator) evalMap(mp parser.HashMapExpression, env *object.Environment) (object.Object, error) {
-	mpObj := object.MapObject{
-		Val: make(map[object.Object]object.Object),
+func (e Evaluator) evalAssign(assign parser.AssignExpression, env *object.Environment) (object.Object, error) {
+	ref, err := e.evalReference(assign.Identifier, env)
+	if err != nil {
+		return nil, err
 	}
 
-	for k, v := range mp.Map {
-		key, err := e.eval(k, env)
+	val, err := e.evalAssignedValue(assign, ref.get, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ref.set(val); err != nil {
+		return nil, err
+	}
+
+	return val, nil
+}
+
+// reference assignable location, containers and indexes of the location are evaluated once,
+// when the reference is created
+type reference struct {
+	get func() (object.Object, error)
+	set func(val object.Object) error
+}
+
+func (e Evaluator) evalReference(expr parser.Expression, env *object.Environment) (reference, error) {
+	switch target := expr.(type) {
+	case parser.IdentifierExpression:
+		return reference{
+			get: func() (object.Object, error) {
+				return e.eval(target, env)
+			},
+			set: func(val object.Object) error {
+				if err := assign(env, target, val); err != nil {
+					return NewRuntimeError(err.Error(), target)
+				}
+
+				return nil
+			},
+		}, nil
+	case parser.IndexExpression:
+		parent, err := e.evalReference(target.Of, env)
 		if err != nil {
-			return nil, err
+			return reference{}, err
+		}
+
+		structure, err := parent.get()
+		if err != nil {
+			return reference{}, err
+		}
+
+		idx, err := e.eval(target.Idx, env)
+		if err != nil {
+			return reference{}, err
+		}
+
+		return reference{
+			get: func() (object.Object, error) {
+				return e.indexObject(target, structure, idx)
+			},
+			set: func(val object.Object) error {
+				return e.assignIndex(target, parent, structure, idx, val)
+			},
+		}, nil
+	case parser.MemberExpression:
+		parent, err := e.evalReference(target.Of, env)
+		if err != nil {
+			return reference{}, err
+		}
+
+		structure, err := parent.get()
+		if err != nil {
+			return reference{}, err
+		}
+
+		return reference{
+			get: func() (object.Object, error) {
+				return e.member(target, structure)
+			},
+			set: func(val object.Object) error {
+				return e.assignMember(target, structure, val)
+			},
+		}, nil
+	default:
+		// value of an arbitrary expression, e.g. f() in f()[0] = 1, can be indexed but not reassigned
+		val, err := e.eval(expr, env)
+		if err != nil {
+			return reference{}, err
+		}
+
+		return reference{
+			get: func() (object.Object, error) {
+				return val, nil
+			},
+			set: func(object.Object) error {
+				return NewRuntimeError("expression is not assignable", expr)
+			},
+		}, nil
+	}
+}
+
+// assignIndex assigns val to structure[idx]. Arrays and maps are changed in place, strings are immutable,
+// so the changed copy is assigned back to the parent reference.
+func (e Evaluator) assignIndex(target parser.IndexExpression, parent reference, structure, idx, val object.Object) error {
+	switch {
+	case structure.Type() == object.MAP_OBJ:
+		mp := structure.(*object.MapObject)
+		if mp.Frozen {
+			return NewRuntimeError("can not modify frozen map", target)
+		}
+
+		if _, ok := mp.Get(idx); !ok {
+			if err := e.budget.Map(len(mp.Val)+1, 1); err != nil {
+				return limitError(err, target)
+			}
+		}
+
+		mp.Set(idx, val)
+		return nil
+	case structure.Type() == object.ARRAY_OBJ && idx.Type() == object.INTEGER_OBJ:
+		if structure.(*object.ArrayObject).Frozen {
+			return NewRuntimeError("can not modify frozen array", target)
+		}
+
+		arr := structure.(*object.ArrayObject).Val
+		index, ok := normalizeIndex(idx.(object.IntegerObject).Val, len(arr))
+		if !ok {
+			return NewRuntimeError("index out of bounds", target)
+		}
+
+		arr[index] = val
+		return nil
+	case structure.Type() == object.STRING_OBJ && idx.Type() == object.INTEGER_OBJ:
+		// strings are indexed by characters, not bytes
+		str := []rune(structure.(object.StringObject).Val)
+		value, ok := val.(object.StringObject)
+		if !ok || utf8.RuneCountInString(value.Val) != 1 {
+			return NewRuntimeError("assignment by index to the string must contains only one character", target)
+		}
+
+		index, ok := normalizeIndex(idx.(object.IntegerObject).Val, len(str))
+		if !ok {
+			return NewRuntimeError("index out of bounds", target)
+		}
+
+		str[index] = []rune(value.Val)[0]
+		return parent.set(object.StringObject{
+			Val: string(str),
+		})
+	default:
+		return NewRuntimeError("unsupported assignment", target)
+	}
+}
+
+// member reads the field of the struct or the enum value, fields of maps are string keys, missing keys are nil.
+// Variants of the enum are its members as well.
+func (e Evaluator) member(expr parser.MemberExpression, of object.Object) (object.Object, error) {
+	name := expr.Field.Identifier.Literal
+	switch v := of.(type) {
+	case *object.StructObject:
+		i, ok := v.Def.FieldIndex(name)
+		if !ok {
+			return nil, NewRuntimeError(fmt.Sprintf("struct %s has no field %s", v.Def.Name, name), expr)
+		}
+
+		return v.Val[i], nil
+	case *object.EnumObject:
+		i, ok := v.Variant.FieldIndex(name)
+		if !ok {
+			return nil, NewRuntimeError(fmt.Sprintf("variant %s has no field %s", v.Variant.Name, name), expr)
+		}
+
+		return v.Val[i], nil
+	case *object.EnumType:
+		variant, ok := v.Variant(name)
+		if !ok {
+			return nil, NewRuntimeError(fmt.Sprintf("enum %s has no variant %s", v.Name, name), expr)
+		}
+
+		return variantValue(variant), nil
+	case *object.MapObject:
+		val, ok := v.Val[object.StringObject{Val: name}]
+		if !ok {
+			return object.NIL, nil
+		}
+
+		return val, nil
+	case object.ErrorObject:
+		if name == "message" {
+			return object.StringObject{Val: v.Message}, nil
+		}
+	}
+
+	return nil, NewRuntimeError(fmt.Sprintf("can not access field %s of %s", name, of.Type()), expr)
+}
+
+func (e Evaluator) assignMember(expr parser.MemberExpression, of, val object.Object) error {
+	name := expr.Field.Identifier.Literal
+	switch v := of.(type) {
+	case *object.StructObject:
+		if v.Frozen {
+			return NewRuntimeError("can not modify frozen struct", expr)
+		}
+
+		i, ok := v.Def.FieldIndex(name)
+		if !ok {
+			return NewRuntimeError(fmt.Sprintf("struct %s has no field %s", v.Def.Name, name), expr)
+		}
+
+		if err := e.guard(v.Def.Fields[i].Type, val, "field "+name, expr); err != nil {
+			return err
+		}
+
+		v.Val[i] = val
+		return nil
+	case *object.MapObject:
+		if v.Frozen {
+			return NewRuntimeError("can not modify frozen map", expr)
+		}
+
+		key := object.StringObject{Val: name}
+		if _, ok := v.Val[key]; !ok {
+			if err := e.budget.Map(len(v.Val)+1, 1); err != nil {
+				return limitError(err, expr)
+			}
+		}
+
+		v.Val[key] = val
+		return nil
+	}
+
+	return NewRuntimeError(fmt.Sprintf("can not assign field %s of %s", name, of.Type()), expr)
+}
+
+func (e Evaluator) evalStructExpression(expr parser.StructExpression, env *object.Environment) (object.Object, error) {
+	def, err := e.eval(expr.Name, env)
+	if err != nil {
+		return nil, err
+	}
+
+	st, ok := def.(*object.StructType)
+	if !ok {
+		return nil, NewRuntimeError(fmt.Sprintf("%s is not a struct", expr.Name), expr)
+	}
+
+	named := make(map[string]object.Object, len(expr.Fields))
+	for _, field := range expr.Fields {
+		name := field.Name.Identifier.Literal
+		if _, ok := named[name]; ok {
+			return nil, NewRuntimeError(fmt.Sprintf("field %s is given more than once", name), field)
+		}
+
+		val, err := e.eval(field.Val, env)
+		if err != nil {
+			return nil, err
+		}
+
+		named[name] = val
+	}
+
+	return e.construct(expr, st, nil, named)
+}
+
+// construct creates the struct, positional values are assigned to the fields in the order of the declaration,
+// the remaining fields must be given by name
+func (e Evaluator) construct(expr parser.Node, st *object.StructType, positional []object.Object, named map[string]object.Object) (object.Object, error) {
+	vals, err := e.fieldValues(expr, "struct "+st.Name, st.Fields, positional, named)
+	if err != nil {
+		return nil, err
+	}
+
+	return &object.StructObject{
+		Def: st,
+		Val: vals,
+	}, nil
+}
+
+// fieldValues orders the arguments of the struct or variant constructor by the declaration of the fields,
+// owner names the declaration in error messages
+func (e Evaluator) fieldValues(expr parser.Node, owner string, fields []parser.Field, positional []object.Object, named map[string]object.Object) ([]object.Object, error) {
+	if len(positional) > len(fields) {
+		return nil, NewRuntimeError(fmt.Sprintf("%s has %d fields, got %d arguments", owner, len(fields), len(positional)), expr)
+	}
+
+	index := make(map[string]int, len(fields))
+	for i, field := range fields {
+		index[field.Name.Identifier.Literal] = i
+	}
+
+	for name := range named {
+		i, ok := index[name]
+		if !ok {
+			return nil, NewRuntimeError(fmt.Sprintf("%s has no field %s", owner, name), expr)
+		}
+
+		if i < len(positional) {
+			return nil, NewRuntimeError(fmt.Sprintf("field %s is given more than once", name), expr)
+		}
+	}
+
+	vals := make([]object.Object, len(fields))
+	for i, field := range fields {
+		name := field.Name.Identifier.Literal
+		val, ok := named[name]
+		switch {
+		case i < len(positional):
+			val = positional[i]
+		case !ok:
+			return nil, NewRuntimeError(fmt.Sprintf("missing field %s of %s", name, owner), expr)
+		}
+
+		if err := e.guard(field.Type, val, "field "+name, expr); err != nil {
+			return nil, err
+		}
+
+		vals[i] = val
+	}
+
+	return vals, nil
+}
+
+// evalEnumStatement defines the enum and its variants as constants of the scope
+func (e Evaluator) evalEnumStatement(stmt parser.EnumStatement, env *object.Environment) (object.Object, error) {
+	if env.Declared(stmt.Name.Identifier.Literal) {
+		return nil, NewRuntimeError("identifier is already defined", stmt.Name)
+	}
+
+	for _, variant := range stmt.Variants {
+		if env.Declared(variant.Name.Identifier.Literal) {
+			return nil, NewRuntimeError("identifier is already defined", variant.Name)
+		}
+	}
+
+	en := &object.EnumType{
+		Name:     stmt.Name.Identifier.Literal,
+		Variants: make([]*object.VariantType, 0, len(stmt.Variants)),
+	}
+
+	define(env, stmt.Name, en)
+	makeConst(env, stmt.Name)
+	for _, variant := range stmt.Variants {
+		vt := &object.VariantType{
+			Enum:   en,
+			Name:   variant.Name.Identifier.Literal,
+			Fields: variant.Fields,
+		}
+
+		en.Variants = append(en.Variants, vt)
+		define(env, variant.Name, variantValue(vt))
+		makeConst(env, variant.Name)
+	}
+
+	return en, nil
+}
+
+// variantValue returns the value bound to the name of the variant, variants without fields are values
+// and the others are constructors
+func variantValue(variant *object.VariantType) object.Object {
+	if len(variant.Fields) == 0 {
+		return &object.EnumObject{Variant: variant}
+	}
+
+	return variant
+}
+
+// evalAssignedValue evaluates the right side of the assignment, for compound assignment it is combined with
+// the current value of the target, which is read with current only once.
+func (e Evaluator) evalAssignedValue(assign parser.AssignExpression, current func() (object.Object, error), env *object.Environment) (object.Object, error) {
+	operator, ok := assign.Operator()
+	if !ok {
+		return e.eval(assign.Val, env)
+	}
+
+	left, err := current()
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := e.eval(assign.Val, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.evalInfixObjects(&parser.InfixExpression{
+		Left:     assign.Identifier,
+		Operator: operator,
+		Right:    assign.Val,
+	}, left, right)
+}
+
+func (e Evaluator) evalMatch(match parser.MatchExpression, env *object.Environment) (object.Object, error) {
+	val, err := e.eval(match.Value, env)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arm := range match.Arms {
+		armEnv := object.DeriveEnv(env)
+		ok, err := e.matchPattern(arm.Pattern, val, armEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if arm.Guard != nil {
+			guard, err := e.eval(arm.Guard, armEnv)
+			if err != nil {
+				return nil, err
+			}
+
+			cond, err := e.evalObjToBool(guard)
+			if err != nil {
+				return nil, NewRuntimeError(err.Error(), arm.Guard)
+			}
+
+			if !cond.Val {
+				continue
+			}
+		}
+
+		return e.evalBlockStatement(arm.Body, armEnv)
+	}
+
+	return nil, NewRuntimeError(fmt.Sprintf("no match arm for value %s", val.Inspect()), match)
+}
+
+// bindPattern destructures val into identifiers of the pattern, values of mismatching shape are reported as an error
+func (e Evaluator) bindPattern(pattern parser.Pattern, val object.Object, env *object.Environment) error {
+	ok, err := e.matchPattern(pattern, val, env)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return NewRuntimeError(fmt.Sprintf("value %s does not match pattern %s", val.Inspect(), pattern), pattern)
+	}
+
+	return nil
+}
+
+// matchPattern checks if val matches the pattern, identifiers of the pattern are defined in env
+// as soon as they are matched, so env is expected to be dropped if the whole pattern does not match
+func (e Evaluator) matchPattern(pattern parser.Pattern, val object.Object, env *object.Environment) (bool, error) {
+	switch p := pattern.(type) {
+	case parser.WildcardPattern:
+		return true, nil
+	case parser.IdentifierExpression:
+		define(env, p, val)
+		return true, nil
+	case parser.ArrayPattern:
+		arr, ok := val.(*object.ArrayObject)
+		if !ok {
+			return false, nil
+		}
+
+		if len(arr.Val) < len(p.Elements) || p.Rest == nil && len(arr.Val) != len(p.Elements) {
+			return false, nil
+		}
+
+		for i, element := range p.Elements {
+			if ok, err := e.matchPattern(element, arr.Val[i], env); err != nil || !ok {
+				return ok, err
+			}
+		}
+
+		if p.Rest != nil {
+			rest := arr.Val[len(p.Elements):]
+			define(env, *p.Rest, &object.ArrayObject{
+				Val: append(make([]object.Object, 0, len(rest)), rest...),
+			})
+		}
+
+		return true, nil
+	case parser.MapPattern:
+		mp, ok := val.(*object.MapObject)
+		if !ok {
+			return false, nil
+		}
+
+		for _, entry := range p.Entries {
+			key, err := e.eval(entry.Key, env)
+			if err != nil {
+				return false, err
+			}
+
+			entryVal, ok := mp.Get(key)
+			if !ok {
+				return false, nil
+			}
+
+			if ok, err := e.matchPattern(entry.Pattern, entryVal, env); err != nil || !ok {
+				return ok, err
+			}
+		}
+
+		return true, nil
+	case parser.LiteralPattern:
+		literal, err := e.eval(p.Val, env)
+		if err != nil {
+			return false, err
+		}
+
+		return object.Equal(literal, val), nil
+	case parser.RangePattern:
+		if !object.IsNumber(val) {
+			return false, nil
+		}
+
+		low, err := e.eval(p.Low, env)
+		if err != nil {
+			return false, err
+		}
+
+		high, err := e.eval(p.High, env)
+		if err != nil {
+			return false, err
+		}
+
+		lowCmp, err := object.CompareNumbers(val, low)
+		if err != nil {
+			return false, NewRuntimeError(err.Error(), p)
+		}
+
+		highCmp, err := object.CompareNumbers(val, high)
+		if err != nil {
+			return false, NewRuntimeError(err.Error(), p)
+		}
+
+		return lowCmp >= 0 && (highCmp < 0 || p.Inclusive && highCmp == 0), nil
+	case parser.AlternativePattern:
+		for _, alternative := range p.Alternatives {
+			ok, err := e.matchPattern(alternative, val, env)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+
+		return false, nil
+	case parser.VariantPattern:
+		return e.matchVariant(p, val, env)
+	default:
+		return false, NewRuntimeError("unsupported pattern", pattern)
+	}
+}
+
+// matchVariant matches enum values of the variant, the variant name without fields matches the variant
+// regardless of its fields
+func (e Evaluator) matchVariant(p parser.VariantPattern, val object.Object, env *object.Environment) (bool, error) {
+	def, err := e.eval(p.Name, env)
+	if err != nil {
+		return false, err
+	}
+
+	var variant *object.VariantType
+	switch v := def.(type) {
+	case *object.VariantType:
+		variant = v
+	case *object.EnumObject:
+		variant = v.Variant
+	default:
+		return false, NewRuntimeError(fmt.Sprintf("%s is not a variant", p.Name), p)
+	}
+
+	if p.Fields != nil && len(p.Fields) != len(variant.Fields) {
+		return false, NewRuntimeError(fmt.Sprintf("variant %s has %d fields, pattern has %d", variant.Name, len(variant.Fields), len(p.Fields)), p)
+	}
+
+	en, ok := val.(*object.EnumObject)
+	if !ok || !en.Variant.Same(variant) {
+		return false, nil
+	}
+
+	for i, field := range p.Fields {
+		if ok, err := e.matchPattern(field, en.Val[i], env); err != nil || !ok {
+			return ok, err
+		}
+	}
+
+	return true, nil
+}
+
+func (e Evaluator) evalMap(mp parser.HashMapExpression, env *object.Environment) (object.Object, error) {
+	if err := e.budget.Map(len(mp.Map), len(mp.Map)); err != nil {
+		return nil, limitError(err, mp)
+	}
+
+	mpObj := &object.MapObject{
+		Val: make(map[object.Object]object.Object),
+	}
+
+	for k, v := range mp.Map {
+		key, err := e.eval(k, env)
+		if err != nil {
+			return nil, err
+		}
+		val, err := e.eval(v, env)
+		if err != nil {
+			return nil, err
+		}
+
+		mpObj.Set(key, val)
+	}
+
+	return mpObj, nil
+}
+
+func (e Evaluator) evalArray(arrExpr parser.ArrayExpression, env *object.Environment) (object.Object, error) {
+	if err := e.budget.Array(len(arrExpr.Arr), len(arrExpr.Arr)); err != nil {
+		return nil, limitError(err, arrExpr)
+	}
+
+	arr := &object.ArrayObject{
+		Val: make([]object.Object, 0, len(arrExpr.Arr)),
+	}
+
+	for _, expr := range arrExpr.Arr {
+		obj, err := e.eval(expr, env)
+		if err != nil {
+			return nil, err
+		}
+		arr.Val = append(arr.Val, obj)
+	}
+
+	return arr, nil
+}
+func (e Evaluator) evalIndex(expr parser.IndexExpression, env *object.Environment) (object.Object, error) {
+	ofObj, err := e.eval(expr.Of, env)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := e.eval(expr.Idx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.indexObject(expr, ofObj, idx)
+}
+
+func (e Evaluator) indexObject(expr parser.IndexExpression, ofObj, idx object.Object) (object.Object, error) {
+	switch {
+	case idx.Type() == object.INTEGER_OBJ && ofObj.Type() == object.STRING_OBJ:
+		str := []rune(ofObj.(object.StringObject).Val)
+		index, ok := normalizeIndex(idx.(object.IntegerObject).Val, len(str))
+		if !ok {
+			return nil, NewRuntimeError("index out of bounds", expr)
+		}
+
+		return object.StringObject{
+			Val: string(str[index]),
+		}, nil
+	case idx.Type() == object.INTEGER_OBJ && ofObj.Type() == object.ARRAY_OBJ:
+		arr := ofObj.(*object.ArrayObject).Val
+		index, ok := normalizeIndex(idx.(object.IntegerObject).Val, len(arr))
+		if !ok {
+			return nil, NewRuntimeError("index out of bounds", expr)
+		}
+		return arr[index], nil
+	case idx.Type() == object.INTEGER_OBJ && ofObj.Type() == object.RANGE_OBJ:
+		rng := ofObj.(*object.RangeObject)
+		index := idx.(object.IntegerObject).Val
+		if index < 0 {
+			index += rng.Len()
+		}
+
+		if index < 0 || index >= rng.Len() {
+			return nil, NewRuntimeError("index out of bounds", expr)
+		}
+
+		return object.IntegerObject{Val: rng.At(index)}, nil
+	case ofObj.Type() == object.MAP_OBJ:
+		val, ok := ofObj.(*object.MapObject).Get(idx)
+		if !ok {
+			val = object.NIL
+		}
+		return val, nil
+	default:
+		return nil, NewRuntimeError("unexpected index type for expression", expr)
+	}
+
+}
+
+// normalizeIndex resolves negative index counting from the end, false is returned if the index is out of bounds
+func normalizeIndex(index int64, length int) (int64, bool) {
+	if index < 0 {
+		index += int64(length)
+	}
+
+	return index, index >= 0 && index < int64(length)
+}
+
+// clampSliceBound resolves negative bound counting from the end and clamps it to [0, length]
+func clampSliceBound(bound int64, length int) int64 {
+	if bound < 0 {
+		bound += int64(length)
+	}
+
+	return min(max(bound, 0), int64(length))
+}
+
+func (e Evaluator) evalSlice(expr parser.SliceExpression, env *object.Environment) (object.Object, error) {
+	ofObj, err := e.eval(expr.Of, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var length int
+	switch v := ofObj.(type) {
+	case object.StringObject:
+		length = utf8.RuneCountInString(v.Val)
+	case *object.ArrayObject:
+		length = len(v.Val)
+	default:
+		return nil, NewRuntimeError("slicing is supported only for arrays and strings", expr)
+	}
+
+	bounds := [2]int64{0, int64(length)}
+	for i, boundExpr := range []parser.Expression{expr.Start, expr.End} {
+		if boundExpr == nil {
+			continue
+		}
+
+		bound, err := e.eval(boundExpr, env)
+		if err != nil {
+			return nil, err
+		}
+
+		integer, ok := bound.(object.IntegerObject)
+		if !ok {
+			return nil, NewRuntimeError("slice bounds must be integers", boundExpr)
+		}
+
+		bounds[i] = clampSliceBound(integer.Val, length)
+	}
+
+	start, end := bounds[0], max(bounds[0], bounds[1])
+	if str, ok := ofObj.(object.StringObject); ok {
+		return object.StringObject{
+			Val: string([]rune(str.Val)[start:end]),
+		}, nil
+	}
+
+	arr := ofObj.(*object.ArrayObject).Val[start:end]
+	if err := e.budget.Array(len(arr), len(arr)); err != nil {
+		return nil, limitError(err, expr)
+	}
+
+	return &object.ArrayObject{
+		Val: append(make([]object.Object, 0, len(arr)), arr...),
+	}, nil
+}
+
+func (e Evaluator) evalBlockStatement(stmt parser.BlockStatement, env *object.Environment) (object.Object, error) {
+	var res object.Object = object.NIL
+	for _, stmt := range stmt.Statements {
+		var err error
+		res, err = e.eval(stmt, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if res.Type() == object.RETURN_OBJ {
+			return res, nil
+		}
+	}
+
+	return res, nil
+
+}
+
+func (e Evaluator) evalCallExpression(expr parser.CallExpression, env *object.Environment) (object.Object, error) {
+	if member, ok := expr.Call.(parser.MemberExpression); ok {
+		return e.evalMethodCall(expr, member, env)
+	}
+
+	callObj, err := e.eval(expr.Call, env)
+	if err != nil {
+		return nil, err
+	}
+
+	positional, named, err := e.evalArguments(expr.CallArgs, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.apply(expr, callObj, positional, named)
+}
+
+// evalPipe evaluates x |> f(a) as f(x, a). Names which are not defined call the build in method of x, so
+// xs |> map(f) is the same as xs.map(f).
+func (e Evaluator) evalPipe(pipe parser.PipeExpression, env *object.Environment) (object.Object, error) {
+	if call, ok := pipe.MethodCall(); ok {
+		name := call.Call.(parser.MemberExpression).Field
+		if _, defined := lookup(env, name); !defined && object.BuildIns[name.Identifier.Literal] == nil {
+			return e.evalCallExpression(call, env)
+		}
+	}
+
+	return e.evalCallExpression(pipe.Call(), env)
+}
+
+// evalMethodCall evaluates receiver.name(args). Functions stored in the fields of structs and the keys of maps
+// are called as they are. Methods of structs and build in methods of the receiver type get the receiver as
+// the first argument, otherwise name(receiver, args) is called if the function with such name exists.
+func (e Evaluator) evalMethodCall(expr parser.CallExpression, member parser.MemberExpression, env *object.Environment) (object.Object, error) {
+	receiver, err := e.eval(member.Of, env)
+	if err != nil {
+		return nil, err
+	}
+
+	positional, named, err := e.evalArguments(expr.CallArgs, env)
+	if err != nil {
+		return nil, err
+	}
+
+	name := member.Field.Identifier.Literal
+	switch v := receiver.(type) {
+	case *object.StructObject:
+		if i, ok := v.Def.FieldIndex(name); ok {
+			return e.apply(expr, v.Val[i], positional, named)
+		}
+
+		if method, ok := v.Def.Methods[name]; ok {
+			return e.apply(expr, method, append([]object.Object{receiver}, positional...), named)
+		}
+	case *object.MapObject:
+		if fn, ok := v.Val[object.StringObject{Val: name}]; ok {
+			return e.apply(expr, fn, positional, named)
+		}
+	case *object.EnumType:
+		fn, err := e.member(member, v)
+		if err != nil {
+			return nil, err
+		}
+
+		return e.apply(expr, fn, positional, named)
+	case *object.ChannelObject:
+		res, ok, err := e.channelMethod(v, name, positional)
+		if err != nil {
+			if isStopped(err) {
+				return nil, e.unwind(err, expr)
+			}
+
+			return nil, NewRuntimeError(fmt.Sprintf("%s: %s", name, err), expr)
+		}
+
+		if ok {
+			return res, nil
+		}
+	}
+
+	if method, ok := object.Methods[receiver.Type()][name]; ok {
+		for arg := range named {
+			return nil, NewRuntimeError(fmt.Sprintf("build in method does not accept named argument %s", arg), expr)
+		}
+
+		res, err := method(e.runtime(expr), append([]object.Object{receiver}, positional...)...)
+		if err != nil {
+			if errors.As(err, &RuntimeError{}) {
+				return nil, err
+			}
+
+			if isStopped(err) {
+				return nil, e.unwind(err, expr)
+			}
+
+			return nil, NewRuntimeError(fmt.Sprintf("%s: %s", name, err), expr)
+		}
+
+		return res, nil
+	}
+
+	fn, ok := lookup(env, member.Field)
+	if !ok {
+		fn, ok = object.BuildIns[name]
+	}
+
+	if ok && (fn.Type() == object.FUNC_OBJ || fn.Type() == object.BUILDIN_OBJ || fn.Type() == object.COMPOSED_OBJ) {
+		return e.apply(expr, fn, append([]object.Object{receiver}, positional...), named)
+	}
+
+	return nil, NewRuntimeError(fmt.Sprintf("%s has no method %s", typeName(receiver), name), expr)
+}
+
+// typeName names the type of the value in error messages, structs and enums are named by their declaration
+func typeName(obj object.Object) string {
+	switch v := obj.(type) {
+	case *object.StructObject:
+		return "struct " + v.Def.Name
+	case *object.EnumObject:
+		return "enum " + v.Variant.Enum.Name
+	}
+
+	return string(obj.Type())
+}
+
+// runtime lets build in methods call the functions given as arguments, e.g. the callback of map, and bounds
+// the values they create
+func (e Evaluator) runtime(expr parser.CallExpression) *object.Runtime {
+	return &object.Runtime{
+		Call: func(fn object.Object, args ...object.Object) (object.Object, error) {
+			return e.apply(expr, fn, args, nil)
+		},
+		Budget:       e.budget,
+		Capabilities: e.capabilities,
+		Stdin:        e.stdin,
+		Args:         e.args,
+		Done:         e.ctx.Done(),
+	}
+}
+
+// apply calls the function, build in function or struct constructor with evaluated arguments
+func (e Evaluator) apply(expr parser.CallExpression, callObj object.Object, positional []object.Object, named map[string]object.Object) (object.Object, error) {
+	switch call := callObj.(type) {
+	case object.FuncObject:
+		if err := e.canceled(expr); err != nil {
+			return nil, err
+		}
+
+		e.depth++
+		if err := e.budget.CallDepth(e.depth); err != nil {
+			return nil, limitError(err, expr)
+		}
+
+		// every call gets its own scope for parameters and the body, derived from the scope of the definition
+		callEnv := object.DeriveEnv(call.Env)
+		if err := e.bindArguments(expr, call, positional, named, callEnv); err != nil {
+			return nil, err
+		}
+
+		if call.Generator {
+			return e.generate(call, callEnv), nil
+		}
+
+		res, err := e.evalBody(call.Body, callEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := e.guard(call.ReturnType, res, "result", expr); err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	case object.BuildInFunc:
+		for name := range named {
+			return nil, NewRuntimeError(fmt.Sprintf("build in function does not accept named argument %s", name), expr)
+		}
+
+		res, err := call(e.runtime(expr), positional...)
+		if err != nil && isStopped(err) {
+			return nil, e.unwind(err, expr)
 		}
-		val, err := e.eval(v, env)
+
+		return res, err
+	case *object.StructType:
+		return e.construct(expr, call, positional, named)
+	case *object.VariantType:
+		vals, err := e.fieldValues(expr, "variant "+call.Name, call.Fields, positional, named)
 		if err != nil {
 			return nil, err
 		}
 
-		mpObj.Val[key] = val
+		return &object.EnumObject{Variant: call, Val: vals}, nil
+	case object.ComposedObject:
+		res, err := e.apply(expr, call.Funcs[0], positional, named)
+		for _, fn := range call.Funcs[1:] {
+			if err != nil {
+				return nil, err
+			}
+
+			res, err = e.apply(expr, fn, []object.Object{res}, nil)
+		}
+
+		return res, err
+	default:
+		return nil, NewRuntimeError("expected function expression", expr)
 	}
 
-	return mpObj, nil
 }
 
-func (e Evaluator) evalArray(arrExpr parser.ArrayExpression, env *object.Environment) (object.Object, error) {
-	arr := &object.ArrayObject{
-		Val: make([]object.Object, 0, len(arrExpr.Arr)),
-	}
+// evalArguments evaluates call arguments to positional values with spread arrays expanded and named values
+func (e Evaluator) evalArguments(args []parser.Expression, env *object.Environment) ([]object.Object, map[string]object.Object, error) {
+	positional := make([]object.Object, 0, len(args))
+	var named map[string]object.Object
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case parser.SpreadExpression:
+			obj, err := e.eval(v.Val, env)
+			if err != nil {
+				return nil, nil, err
+			}
 
-	for _, expr := range arrExpr.Arr {
-		obj, err := e.eval(expr, env)
-		if err != nil {
-			return nil, err
+			arr, ok := obj.(*object.ArrayObject)
+			if !ok {
+				return nil, nil, NewRuntimeError(fmt.Sprintf("spread argument must be an array, got %s", obj.Type()), v)
+			}
+
+			positional = append(positional, arr.Val...)
+		case parser.NamedArgument:
+			name := v.Name.Identifier.Literal
+			if _, ok := named[name]; ok {
+				return nil, nil, NewRuntimeError(fmt.Sprintf("argument %s is given more than once", name), v)
+			}
+
+			obj, err := e.eval(v.Val, env)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if named == nil {
+				named = make(map[string]object.Object)
+			}
+
+			named[name] = obj
+		default:
+			obj, err := e.eval(arg, env)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			positional = append(positional, obj)
 		}
-		arr.Val = append(arr.Val, obj)
 	}
 
-	return arr, nil
+	return positional, named, nil
 }
-func (e Evaluator) evalIndex(expr parser.IndexExpression, env *object.Environment) (object.Object, error) {
-	ofObj, err := e.eval(expr.Of, env)
-	if err != nil {
-		return nil, err
-	}
 
-	idx, err := e.eval(expr.Idx, env)
-	if err != nil {
-		return nil, err
-	}
+// bindArguments binds arguments to the function parameters in the call scope, parameters that are not given
+// take the default value evaluated in the call scope, so defaults can refer to the preceding parameters
+func (e Evaluator) bindArguments(expr parser.CallExpression, fn object.FuncObject, positional []object.Object, named map[string]object.Object, env *object.Environment) error {
+	used := 0
+	for _, param := range fn.Args {
+		name, hasName := param.Name()
+		if param.Rest {
+			rest := make([]object.Object, 0, len(positional)-used)
+			if used < len(positional) {
+				rest = append(rest, positional[used:]...)
+			}
 
-	switch {
-	case idx.Type() == object.INTEGER_OBJ && ofObj.Type() == object.STRING_OBJ:
-		index := idx.(object.IntegerObject).Val
-		str := ofObj.(object.StringObject).Val
-		if index >= int64(len(str)) {
-			return nil, NewRuntimeError("index out of bounds", expr)
-		}
+			used = len(positional)
+			if err := e.guard(param.Type, &object.ArrayObject{Val: rest}, "argument "+name, expr); err != nil {
+				return err
+			}
 
-		return object.StringObject{
-			Val: string(str[index]),
-		}, nil
-	case idx.Type() == object.INTEGER_OBJ && ofObj.Type() == object.ARRAY_OBJ:
-		index := idx.(object.IntegerObject).Val
-		arr := ofObj.(*object.ArrayObject).Val
-		if index >= int64(len(arr)) {
-			return nil, NewRuntimeError("index out of bounds", expr)
+			if err := e.bindPattern(param.Pattern, &object.ArrayObject{Val: rest}, env); err != nil {
+				return err
+			}
+
+			continue
 		}
-		return arr[index], nil
-	case ofObj.Type() == object.MAP_OBJ:
-		val, ok := ofObj.(object.MapObject).Val[idx]
-		if !ok {
-			val = object.NIL
+
+		val, isNamed := named[name]
+		if isNamed && hasName {
+			delete(named, name)
 		}
-		return val, nil
-	default:
-		return nil, NewRuntimeError("unexpected index type for expression", expr)
-	}
 
-}
+		switch {
+		case used < len(positional):
+			if isNamed && hasName {
+				return NewRuntimeError(fmt.Sprintf("argument %s is given more than once", name), expr)
+			}
 
-func (e Evaluator) evalBlockStatement(stmt parser.BlockStatement, env *object.Environment) (object.Object, error) {
-	var res object.Object = object.NIL
-	for _, stmt := range stmt.Statements {
-		var err error
-		res, err = e.eval(stmt, env)
-		if err != nil {
-			return nil, err
+			val = positional[used]
+			used++
+		case isNamed && hasName:
+		case param.Default != nil:
+			var err error
+			val, err = e.eval(param.Default, env)
+			if err != nil {
+				return err
+			}
+		default:
+			return NewRuntimeError(fmt.Sprintf("missing argument for parameter %s", param.Pattern), expr)
 		}
 
-		if res.Type() == object.RETURN_OBJ {
-			return res, nil
+		if err := e.guard(param.Type, val, "argument "+param.Pattern.String(), expr); err != nil {
+			return err
 		}
-	}
 
-	return res, nil
+		if err := e.bindPattern(param.Pattern, val, env); err != nil {
+			return err
+		}
+	}
 
-}
+	if used < len(positional) {
+		return NewRuntimeError(fmt.Sprintf("unexpected argument %s, function accepts %d arguments", positional[used].Inspect(), used), expr)
+	}
 
-func (e Evaluator) evalCallExpression(expr parser.CallExpression, env *object.Environment) (object.Object, error) {
-	callObj, err := e.eval(expr.Call, env)
-	if err != nil {
-		return nil, err
+	for name := range named {
+		return NewRuntimeError(fmt.Sprintf("unexpected named argument %s", name), expr)
 	}
 
-	switch call := callObj.(type) {
-	case object.FuncObject:
-		if len(call.Args) != len(expr.CallArgs) {
-			return nil, NewRuntimeError("mismatching number of arguments", expr)
-		}
+	return nil
+}
 
-		objs, err := e.evalExpressions(expr.CallArgs, env)
-		if err != nil {
-			return nil, err
-		}
+// guard checks the value against the type annotation when type guards are enabled
+func (e Evaluator) guard(annotation parser.TypeAnnotation, val object.Object, what string, node parser.Node) error {
+	if !e.typeGuards || annotation == nil {
+		return nil
+	}
 
-		for i, k := range call.Args {
-			call.Env.Set(k.Identifier.Literal, objs[i])
-		}
+	t, err := types.ResolveAnnotation(annotation, typeByName)
+	if err != nil {
+		return NewRuntimeError(err.Error(), annotation)
+	}
 
-		return e.evalStatements(call.Body.Statements, call.Env)
-	case object.BuildInFunc:
-		objs, err := e.evalExpressions(expr.CallArgs, env)
-		if err != nil {
-			return nil, err
-		}
-		return call(objs...)
-	default:
-		return nil, NewRuntimeError("expected function expression", expr)
+	if !types.Conforms(val, t) {
+		return NewRuntimeError(fmt.Sprintf("%s expected %s, got %s", what, t, types.Of(val)), node)
 	}
 
+	return nil
+}
+
+// typeByName resolves names which are not build in types to user defined types, the static checker reports
+// unknown names, at runtime values are only compared with the name of their struct or enum
+func typeByName(name string) (types.Type, bool) {
+	return types.Named{Name: name}, true
 }
 
 func (e Evaluator) evalExpressions(args []parser.Expression, env *object.Environment) ([]object.Object, error) {
@@ -361,6 +1632,29 @@ func (e Evaluator) evalStatements(stmts []parser.Statement, env *object.Environm
 	return res, nil
 }
 
+// evalBody evaluates the body of the function, the result is the value of return or of the last statement if
+// it is an expression, like the value of the block of if. Bodies ending with other statements, e.g. let, return nil.
+func (e Evaluator) evalBody(body parser.BlockStatement, env *object.Environment) (object.Object, error) {
+	res, err := e.evalBlockStatement(body, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Type() == object.RETURN_OBJ {
+		return res.(object.ReturnObject).Val, nil
+	}
+
+	if len(body.Statements) == 0 {
+		return object.NIL, nil
+	}
+
+	if _, ok := body.Statements[len(body.Statements)-1].(parser.ExpressionStatement); !ok {
+		return object.NIL, nil
+	}
+
+	return res, nil
+}
+
 func (e Evaluator) evalInfix(infix *parser.InfixExpression, env *object.Environment) (object.Object, error) {
 	left, err := e.eval(infix.Left, env)
 	if err != nil {
@@ -372,6 +1666,20 @@ func (e Evaluator) evalInfix(infix *parser.InfixExpression, env *object.Environm
 		return nil, err
 	}
 
+	return e.evalInfixObjects(infix, left, right)
+}
+
+func (e Evaluator) evalInfixObjects(infix *parser.InfixExpression, left, right object.Object) (object.Object, error) {
+	switch infix.Operator.Token {
+	case lexer.DOTDOT, lexer.DOTDOTEQ:
+		return e.evalRange(infix, left, right)
+	case lexer.BRSHIFT:
+		// f >> g is the function calling g with the result of f
+		if object.IsCallable(left) && object.IsCallable(right) {
+			return object.Compose(left, right)
+		}
+	}
+
 	switch {
 	case right.Type() == object.INTEGER_OBJ && left.Type() == object.INTEGER_OBJ:
 		return e.evalInfixInteger(infix, left.(object.IntegerObject), right.(object.IntegerObject))
@@ -383,45 +1691,92 @@ func (e Evaluator) evalInfix(infix *parser.InfixExpression, env *object.Environm
 	case right.Type() == object.INTEGER_OBJ && left.Type() == object.BOOL_OBJ:
 		leftInt := e.boolObjToInt(left.(object.BoolObject))
 		return e.evalInfixInteger(infix, leftInt, right.(object.IntegerObject))
+	case object.IsNumber(right) && object.IsNumber(left):
+		return e.evalInfixNumber(infix, left, right)
 	case right.Type() == object.STRING_OBJ && left.Type() == object.STRING_OBJ:
-		return object.StringObject{
-			Val: left.(object.StringObject).Val + right.(object.StringObject).Val,
-		}, nil
-	case right.Type() == object.STRING_OBJ && left.Type() == object.INTEGER_OBJ:
-		return object.StringObject{
-			Val: fmt.Sprint(left.(object.IntegerObject).Val) + right.(object.StringObject).Val,
-		}, nil
-	case right.Type() == object.INTEGER_OBJ && left.Type() == object.STRING_OBJ:
-		return object.StringObject{
-			Val: left.(object.StringObject).Val + fmt.Sprint(right.(object.IntegerObject).Val),
-		}, nil
+		return e.concat(infix, left.(object.StringObject).Val, right.(object.StringObject).Val)
+	case right.Type() == object.STRING_OBJ && object.IsNumber(left):
+		return e.concat(infix, left.Inspect(), right.(object.StringObject).Val)
+	case object.IsNumber(right) && left.Type() == object.STRING_OBJ:
+		return e.concat(infix, left.(object.StringObject).Val, right.Inspect())
+	case right.Type() == object.ENUM_OBJ && left.Type() == object.ENUM_OBJ:
+		switch infix.Operator.Literal {
+		case "==":
+			return e.nativeBoolToObj(object.Equal(left, right)), nil
+		case "!=":
+			return e.nativeBoolToObj(!object.Equal(left, right)), nil
+		}
 	}
 
 	return nil, NewRuntimeError("not supported types", infix)
 }
 
+// concat joins the strings, the length of the result is checked before it is created
+func (e Evaluator) concat(infix *parser.InfixExpression, left, right string) (object.Object, error) {
+	if err := e.budget.String(len(left) + len(right)); err != nil {
+		return nil, limitError(err, infix)
+	}
+
+	return object.StringObject{
+		Val: left + right,
+	}, nil
+}
+
+// allocBigInt accounts the big integer result of the operator before it is computed
+func (e Evaluator) allocBigInt(infix *parser.InfixExpression, bits uint64) error {
+	if err := e.budget.BigInt(bits); err != nil {
+		return limitError(err, infix)
+	}
+
+	return nil
+}
+
+// evalRange creates the range from integer bounds, a..b excludes b and a..=b includes it
+func (e Evaluator) evalRange(infix *parser.InfixExpression, left, right object.Object) (object.Object, error) {
+	start, ok := left.(object.IntegerObject)
+	end, isInt := right.(object.IntegerObject)
+	if !ok || !isInt {
+		return nil, NewRuntimeError(fmt.Sprintf("range bounds must be integers, got %s and %s", left.Type(), right.Type()), infix)
+	}
+
+	return object.NewRange(start.Val, end.Val, infix.Operator.Token == lexer.DOTDOTEQ), nil
+}
+
 func (e Evaluator) evalInfixInteger(infix *parser.InfixExpression, left, right object.IntegerObject) (object.Object, error) {
 	switch infix.Operator.Literal {
-	case "+":
-		return object.IntegerObject{
-			Val: left.Val + right.Val,
-		}, nil
-	case "-":
-		return object.IntegerObject{
-			Val: left.Val - right.Val,
-		}, nil
+	case "+", "-":
+		if err := e.allocBigInt(infix, object.AddBits(object.IntBits(left.Val), object.IntBits(right.Val))); err != nil {
+			return nil, err
+		}
+
+		if infix.Operator.Literal == "+" {
+			return object.AddInt(left.Val, right.Val), nil
+		}
+
+		return object.SubInt(left.Val, right.Val), nil
 	case "*":
-		return object.IntegerObject{
-			Val: left.Val * right.Val,
-		}, nil
+		if err := e.allocBigInt(infix, object.MulBits(object.IntBits(left.Val), object.IntBits(right.Val))); err != nil {
+			return nil, err
+		}
+
+		return object.MulInt(left.Val, right.Val), nil
 	case "/":
 		if right.Val == 0 {
 			return nil, NewRuntimeError("zero division", infix.Right)
 		}
 
+		return object.DivInt(left.Val, right.Val), nil
+	case "%":
+		if right.Val == 0 {
+			return nil, NewRuntimeError("zero division", infix.Right)
+		}
+
 		return object.IntegerObject{
-			Val: left.Val / right.Val,
+			Val: left.Val % right.Val,
 		}, nil
+	case "**":
+		res, err := object.Pow(e.budget, left, right)
+		return res, limitError(err, infix)
 	case "==":
 		return e.nativeBoolToObj(left.Val == right.Val), nil
 	case "!=":
@@ -442,11 +1797,25 @@ func (e Evaluator) evalInfixInteger(infix *parser.InfixExpression, left, right o
 		return object.IntegerObject{
 			Val: left.Val | right.Val,
 		}, nil
-	case "<<":
+	case "^":
 		return object.IntegerObject{
-			Val: left.Val << right.Val,
+			Val: left.Val ^ right.Val,
 		}, nil
+	case "<<":
+		if right.Val < 0 {
+			return nil, NewRuntimeError("negative shift count", infix.Right)
+		}
+
+		if err := e.allocBigInt(infix, object.LshBits(big.NewInt(left.Val), uint64(right.Val))); err != nil {
+			return nil, err
+		}
+
+		return object.LshInt(left.Val, uint(right.Val)), nil
 	case ">>":
+		if right.Val < 0 {
+			return nil, NewRuntimeError("negative shift count", infix.Right)
+		}
+
 		return object.IntegerObject{
 			Val: left.Val >> right.Val,
 		}, nil
@@ -463,6 +1832,133 @@ func (e Evaluator) evalInfixInteger(infix *parser.InfixExpression, left, right o
 	}
 }
 
+// evalInfixNumber evaluates mixed number operands, any float operand makes the operation a float one,
+// otherwise at least one of the operands is a big integer.
+func (e Evaluator) evalInfixNumber(infix *parser.InfixExpression, left, right object.Object) (object.Object, error) {
+	if left.Type() == object.FLOAT_OBJ || right.Type() == object.FLOAT_OBJ {
+		l, _ := object.ToFloat(left)
+		r, _ := object.ToFloat(right)
+		return e.evalInfixFloat(infix, l, r)
+	}
+
+	l, _ := object.ToBig(left)
+	r, _ := object.ToBig(right)
+	return e.evalInfixBigInt(infix, l, r)
+}
+
+func (e Evaluator) evalInfixBigInt(infix *parser.InfixExpression, left, right *big.Int) (object.Object, error) {
+	switch infix.Operator.Literal {
+	case "+", "-":
+		if err := e.allocBigInt(infix, object.AddBits(left.BitLen(), right.BitLen())); err != nil {
+			return nil, err
+		}
+
+		if infix.Operator.Literal == "+" {
+			return object.NewBigInt(new(big.Int).Add(left, right)), nil
+		}
+
+		return object.NewBigInt(new(big.Int).Sub(left, right)), nil
+	case "*":
+		if err := e.allocBigInt(infix, object.MulBits(left.BitLen(), right.BitLen())); err != nil {
+			return nil, err
+		}
+
+		return object.NewBigInt(new(big.Int).Mul(left, right)), nil
+	case "/":
+		if right.Sign() == 0 {
+			return nil, NewRuntimeError("zero division", infix.Right)
+		}
+
+		return object.NewBigInt(new(big.Int).Quo(left, right)), nil
+	case "%":
+		if right.Sign() == 0 {
+			return nil, NewRuntimeError("zero division", infix.Right)
+		}
+
+		return object.NewBigInt(new(big.Int).Rem(left, right)), nil
+	case "**":
+		res, err := object.Pow(e.budget, object.BigIntObject{Val: left}, object.BigIntObject{Val: right})
+		return res, limitError(err, infix)
+	case "==":
+		return e.nativeBoolToObj(left.Cmp(right) == 0), nil
+	case "!=":
+		return e.nativeBoolToObj(left.Cmp(right) != 0), nil
+	case ">":
+		return e.nativeBoolToObj(left.Cmp(right) > 0), nil
+	case "<":
+		return e.nativeBoolToObj(left.Cmp(right) < 0), nil
+	case ">=":
+		return e.nativeBoolToObj(left.Cmp(right) >= 0), nil
+	case "<=":
+		return e.nativeBoolToObj(left.Cmp(right) <= 0), nil
+	case "&":
+		return object.NewBigInt(new(big.Int).And(left, right)), nil
+	case "|":
+		return object.NewBigInt(new(big.Int).Or(left, right)), nil
+	case "^":
+		return object.NewBigInt(new(big.Int).Xor(left, right)), nil
+	case "<<", ">>":
+		if !right.IsInt64() || right.Sign() < 0 {
+			return nil, NewRuntimeError("invalid shift count", infix.Right)
+		}
+
+		if infix.Operator.Literal == "<<" {
+			if err := e.allocBigInt(infix, object.LshBits(left, uint64(right.Int64()))); err != nil {
+				return nil, err
+			}
+
+			return object.NewBigInt(new(big.Int).Lsh(left, uint(right.Int64()))), nil
+		}
+
+		return object.NewBigInt(new(big.Int).Rsh(left, uint(right.Int64()))), nil
+	case "||":
+		return e.nativeBoolToObj(left.Sign() > 0 || right.Sign() > 0), nil
+	case "&&":
+		return e.nativeBoolToObj(left.Sign() > 0 && right.Sign() > 0), nil
+	default:
+		return nil, NewRuntimeError("operator is not supported for int types", infix)
+	}
+}
+
+func (e Evaluator) evalInfixFloat(infix *parser.InfixExpression, left, right float64) (object.Object, error) {
+	switch infix.Operator.Literal {
+	case "+":
+		return object.FloatObject{Val: left + right}, nil
+	case "-":
+		return object.FloatObject{Val: left - right}, nil
+	case "*":
+		return object.FloatObject{Val: left * right}, nil
+	case "/":
+		if right == 0 {
+			return nil, NewRuntimeError("zero division", infix.Right)
+		}
+
+		return object.FloatObject{Val: left / right}, nil
+	case "%":
+		if right == 0 {
+			return nil, NewRuntimeError("zero division", infix.Right)
+		}
+
+		return object.FloatObject{Val: math.Mod(left, right)}, nil
+	case "**":
+		return object.FloatObject{Val: math.Pow(left, right)}, nil
+	case "==":
+		return e.nativeBoolToObj(left == right), nil
+	case "!=":
+		return e.nativeBoolToObj(left != right), nil
+	case ">":
+		return e.nativeBoolToObj(left > right), nil
+	case "<":
+		return e.nativeBoolToObj(left < right), nil
+	case ">=":
+		return e.nativeBoolToObj(left >= right), nil
+	case "<=":
+		return e.nativeBoolToObj(left <= right), nil
+	default:
+		return nil, NewRuntimeError("operator is not supported for float types", infix)
+	}
+}
+
 func (e Evaluator) evalBoolInfix(infix *parser.InfixExpression, left, right object.BoolObject) (object.Object, error) {
 	switch infix.Operator.Literal {
 	case "==":
@@ -498,6 +1994,16 @@ func (e Evaluator) evalPrefix(node parser.PrefixExpression, env *object.Environm
 		}
 
 		return res, nil
+	case "~":
+		switch v := right.(type) {
+		case object.IntegerObject:
+			v.Val = ^v.Val
+			return v, nil
+		case object.BigIntObject:
+			return object.NewBigInt(new(big.Int).Not(v.Val)), nil
+		}
+
+		return nil, NewRuntimeError("bitwise not is supported only for int types", node)
 	}
 
 	return nil, NewRuntimeError("unexpected prefix operator", node)
@@ -506,6 +2012,10 @@ func (e Evaluator) evalPrefix(node parser.PrefixExpression, env *object.Environm
 func (e Evaluator) evalMinusPrefix(obj object.Object) (object.Object, error) {
 	switch v := obj.(type) {
 	case object.IntegerObject:
+		return object.NegInt(v.Val), nil
+	case object.BigIntObject:
+		return object.NewBigInt(new(big.Int).Neg(v.Val)), nil
+	case object.FloatObject:
 		v.Val = -v.Val
 		return v, nil
 	}
@@ -514,20 +2024,7 @@ func (e Evaluator) evalMinusPrefix(obj object.Object) (object.Object, error) {
 }
 
 func (e Evaluator) evalObjToBool(obj object.Object) (object.BoolObject, error) {
-	switch v := obj.(type) {
-	case object.NilObject:
-		return object.FALSE, nil
-	case object.BoolObject:
-		return v, nil
-	case object.IntegerObject:
-		if v.Val >= 1 {
-			return object.TRUE, nil
-		}
-		return object.FALSE, nil
-	default:
-		return object.FALSE, fmt.Errorf("unexpected node")
-
-	}
+	return object.ToBool(obj)
 }
 
 func (e Evaluator) boolObjToInt(obj object.BoolObject) object.IntegerObject {
@@ -2,13 +2,21 @@ package eval
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/charkpep/yami/src/lexer"
 	"github.com/charkpep/yami/src/object"
 	"github.com/charkpep/yami/src/parser"
+	"github.com/charkpep/yami/src/resolver"
 	"io"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func EvaluateProgram(t *testing.T, in io.Reader) object.Object {
@@ -32,6 +40,27 @@ func EvaluateProgram(t *testing.T, in io.Reader) object.Object {
 	return obj
 }
 
+// EvaluateResolved evaluates the program with variables resolved to slots, returns false if the resolver
+// rejects the program
+func EvaluateResolved(t *testing.T, in string) (object.Object, bool) {
+	p := parser.NewParser(bytes.NewBufferString(in))
+	root, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resolver.HasErrors(resolver.New().Resolve(root).Diagnostics) {
+		return nil, false
+	}
+
+	obj, err := NewEvaluator().Eval(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return obj, true
+}
+
 func AssertObjects(t *testing.T, a, b object.Object) bool {
 	t.Logf("Asserting %T and %T, %+v, %+v\n", a, b, a, b)
 	if reflect.ValueOf(a).Kind() != reflect.ValueOf(b).Kind() {
@@ -86,14 +115,14 @@ func AssertObjects(t *testing.T, a, b object.Object) bool {
 				return false
 			}
 		}
-	case object.MapObject:
-		if len(v.Val) != len(b.(object.MapObject).Val) {
+	case *object.MapObject:
+		if len(v.Val) != len(b.(*object.MapObject).Val) {
 			t.Errorf("failed to assert map lengthes")
 			return false
 		}
 
 		for k := range v.Val {
-			if !AssertObjects(t, v.Val[k], b.(object.MapObject).Val[k]) {
+			if !AssertObjects(t, v.Val[k], b.(*object.MapObject).Val[k]) {
 				return false
 			}
 		}
@@ -136,6 +165,204 @@ func TestEvalArithmetic(t *testing.T) {
 		{"1 || 1", "true"},
 		{"(256 >> 7 < 256 >> 6) || 256 << 7 ", "true"},
 		{"(256 >> 7 < 256 >> 6) && 256 << 7 ", "true"},
+		{"1.5", "1.5"},
+		{"1.5 + 1", "2.5"},
+		{"3 / 2.0", "1.5"},
+		{"-2.0", "-2.0"},
+		{"0.5 < 1", "true"},
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"4611686018427387904 * 4", "18446744073709551616"},
+		{"1 << 64", "18446744073709551616"},
+		{"(9223372036854775807 + 1) - 1", "9223372036854775807"},
+		{"99999999999999999999 / 10", "9999999999999999999"},
+		{"9223372036854775808 > 1", "true"},
+		{`"n = " + 9223372036854775808`, "n = 9223372036854775808"},
+		{`math["abs"](-5)`, "5"},
+		{`math["abs"](-1.5)`, "1.5"},
+		{`math["min"](3, 1, 2)`, "1"},
+		{`math["max"]([3, 1.5, 2])`, "3"},
+		{`math["pow"](2, 10)`, "1024"},
+		{`math["pow"](2, 100)`, "1267650600228229401496703205376"},
+		{`math["pow"](2, -1)`, "0.5"},
+		{`math["sqrt"](16)`, "4.0"},
+		{`math["floor"](1.7)`, "1"},
+		{`math["ceil"](1.2)`, "2"},
+		{`math["floor"](-1.2)`, "-2"},
+		{`math["gcd"](12, 18)`, "6"},
+		{`math["clamp"](15, 0, 10)`, "10"},
+		{`math["clamp"](-1, 0, 10)`, "0"},
+		{"7 % 3", "1"},
+		{"-7 % 3", "-1"},
+		{"7.5 % 2", "1.5"},
+		{"6 ^ 3", "5"},
+		{"~5", "-6"},
+		{"2 ** 10", "1024"},
+		{"2 ** 3 ** 2", "512"},
+		{"-2 ** 2", "-4"},
+		{"2 ** -1", "0.5"},
+		{"2 ** 64", "18446744073709551616"},
+		{"1 + 2 * 3 ** 2 % 5", "4"},
+		{"1 | 6 ^ 3 & 1", "7"},
+		{"[1, 2, 3][-1]", "3"},
+		{`"hello"[-5]`, "h"},
+		{"[1, 2, 3, 4][1:3]", "[2,3]"},
+		{"[1, 2, 3, 4][:2]", "[1,2]"},
+		{"[1, 2, 3, 4][2:]", "[3,4]"},
+		{"[1, 2, 3, 4][-2:]", "[3,4]"},
+		{"[1, 2, 3, 4][:-1]", "[1,2,3]"},
+		{"[1, 2, 3, 4][:]", "[1,2,3,4]"},
+		{"[1, 2, 3, 4][3:1]", "[]"},
+		{"[1, 2, 3, 4][-10:10]", "[1,2,3,4]"},
+		{`"hello"[1:3]`, "el"},
+		{`"hello"[-3:]`, "llo"},
+		{"let a = [1, 2]; a[-1] = 5; a", "[1,5]"},
+		{`let s = "abc"; s[-1] = "x"; s`, "abx"},
+		{`let s = "héllo"; s[1] = "e"; s[-1] = "ö"; [s, s[1], s[-1], s[1:3]]`, "[hellö,e,ö,el]"},
+		{`let s = "日本語"; [s[1], s[-1], s[:2], s[1:]]`, "[本,語,日本,本語]"},
+		{"let a = [1, 2, 3]; let b = a[:]; b[0] = 10; a[0]", "1"},
+		{`let a = 5; if a < 0 { "negative" } else if a == 0 { "zero" } else { "positive" }`, "positive"},
+		{`let a = 0; if a < 0 { "negative" } else if a == 0 { "zero" } else { "positive" }`, "zero"},
+		{`if false { 1 } else if false { 2 }`, "nil"},
+		{`match 1 { 1 => "one", 2 => "two", _ => "many" }`, "one"},
+		{`match 5 { 1 => "one", 2 => "two", _ => "many" }`, "many"},
+		{`match "b" { "a" | "b" => "first", _ => "other" }`, "first"},
+		{`match -3 { -5..0 => "negative", 0..=10 => "small", _ => "large" }`, "negative"},
+		{`match 10 { 0..10 => "exclusive", 0..=10 => "inclusive" }`, "inclusive"},
+		{`match 2.5 { 0..3 => "in", _ => "out" }`, "in"},
+		{`let n = 15; match n { _ if n > 10 => "big", _ => "small" }`, "big"},
+		{`let x = match true { true => { let a = 1; a + 1 } false => 0 }; x`, "2"},
+		{`match 1 { 1.0 => "equal" }`, "equal"},
+		{"let [a, b, ...rest] = [1, 2, 3, 4]; [a, b, rest]", "[1,2,[3,4]]"},
+		{"let f = fn(x, step = 1) { x + step }; [f(1), f(1, 5), f(1, step: 10), f(step: 2, x: 3)]", "[2,6,11,5]"},
+		{"const limit = 10; limit * 2", "20"},
+		{"let v: int | nil = nil; v", "nil"},
+		{"let f = fn(x: int, y: string = \"a\") -> string { y + x }; f(1)", "a1"},
+		{"let i = 10; let f = fn() { let i = 0; i + 1 }; [f(), i]", "[1,10]"},
+		{"let f = fn() { let a = 1; a }; f(); f()", "1"},
+		{"let x = 1; if true { let x = 2; x = 3 }; x", "1"},
+		{"let x = 1; if true { x = 2 } else { x = 3 }; x", "2"},
+		{"let x = 1; { let x = 2 }; x", "1"},
+		{"let f = fn(n) { if n < 2 { return n }; f(n - 1) + f(n - 2) }; f(10)", "55"},
+		{"let counter = fn() { let n = 0; fn() { n += 1 } }; let c = counter(); c(); c()", "2"},
+		{"const [low, high] = [1, 5]; high - low", "4"},
+		{"let arr = freeze([1, 2]); let copy = arr[0:2]; copy[0] = 5; [arr, copy]", "[[1,2],[5,2]]"},
+		{"let arr = freeze([[1], 2]); arr[0][0] = 3; arr", "[[3],2]"},
+		{"let s = freeze(\"abc\"); s", "abc"},
+		{"let m = {\"a\": 1}; let alias = m; freeze(m); alias[\"a\"]", "1"},
+		{"let f = fn(a, b = a * 2) { [a, b] }; f(3)", "[3,6]"},
+		{"let f = fn(first, ...others) { [first, others] }; [f(1), f(1, 2, 3)]", "[[1,[]],[1,[2,3]]]"},
+		{"let f = fn(a, b, c) { a + b + c }; let arr = [2, 3]; f(1, ...arr)", "6"},
+		{"let f = fn(...all) { len(all) }; f(...[1, 2], 3, ...[])", "3"},
+		{"len(...[[1, 2, 3]])", "3"},
+		{"let [head, ...tail] = [1]; tail", "[]"},
+		{"let [_, second] = [1, 2]; second", "2"},
+		{"let [x, [y, z]] = [1, [2, 3]]; x + y + z", "6"},
+		{`let {name, age: years} = {"name": "bob", "age": 3}; name + years`, "bob3"},
+		{`let {"a": [first]} = {"a": [7]}; first`, "7"},
+		{"let f = fn([a, b], {k}) { a + b + k }; f([1, 2], {\"k\": 3})", "6"},
+		{`match [1, 2] { [] => "empty", [x] => "one", [x, ...rest] => x + len(rest) }`, "2"},
+		{`match [] { [] => "empty", _ => "other" }`, "empty"},
+		{`match {"kind": "ok", "value": 5} { {kind: "err"} => 0, {kind: "ok", value} => value }`, "5"},
+		{`match [3, 4] { [a, b] if a > b => "desc", [a, b] => "asc" }`, "asc"},
+		{"let x = 1; match 5 { x => x }; x", "1"},
+		{`match ["a", 1] { ["a" | "b", n] => n }`, "1"},
+		{`struct Point { x, y }; Point(1, "a")`, "Point{x:1,y:a}"},
+		{"struct Point { x, y }; Point{y: 2, x: 1}", "Point{x:1,y:2}"},
+		{"struct Point { x, y }; Point(1, y: 5).y", "5"},
+		{"struct Point { x, y }; let p = Point(1, 2); p.x = p.x + 10; p.y *= 3; p.x + p.y", "17"},
+		{"struct Box { val }; let b = Box(Box([1])); b.val.val[0] = 2; b", "Box{val:Box{val:[2]}}"},
+		{"struct P { x }; let p = P(1); let q = p; q.x = 3; p.x", "3"},
+		{"let f = fn(n) { struct Pair { a, b }; let p = Pair{a: n, b: n * 2}; p.a + p.b }; f(2)", "6"},
+		{"struct P { x }; if P(1).x > 0 { \"pos\" } else { \"neg\" }", "pos"},
+		{`let m = {"a": 1}; m.b = m.a + 1; m["b"]`, "2"},
+		{`let m = {"inner": {"k": "v"}}; m.inner.k`, "v"},
+		{"let m = {}; m.missing", "nil"},
+		{"struct Point { x, y }; Point", "struct Point{x,y}"},
+		{"struct P { x, y, fn norm(self) { self.x * self.x + self.y * self.y } }; P(1, 2).norm()", "5"},
+		{"struct P { x, fn add(p, o) { P(p.x + o.x) } fn get(p) { p.x } }; P(1).add(P(2)).add(P(3)).get()", "6"},
+		{"struct P { x, fn get(self) { self.x } }; let p = P(1); p.x = 5; p.get()", "5"},
+		{"struct S { f }; S(fn(x) { x + 1 }).f(1)", "2"},
+		{"enum Result { Ok(value), Err(message) }; [Ok(1), Err(\"bad\")]", "[Ok(1),Err(bad)]"},
+		{"enum Option { Some(v), None }; [None, Option.None, Option.Some(2), Some]", "[None,None,Some(2),Option.Some(v)]"},
+		{"enum Option { Some(v), None }; Option", "enum Option{Some(v),None}"},
+		{"enum Result { Ok(value), Err(message) }; Ok(value: 3).value", "3"},
+		{"enum Option { Some(v), None }; [Some(1) == Some(1), Some(1) == Some(2), None == None, None != Some(1)]", "[true,false,true,true]"},
+		{"enum Result { Ok(value), Err(message) }; let m = {}; m[Ok(1)] = 1; m[Ok(1)] += 1; m[Err(1)] = 3; [m[Ok(1)], m.has(Err(1)), m.has(Ok(2)), m.delete(Ok(1)), m.len()]", "[2,true,false,2,1]"},
+		{"enum R { Ok(v), Err(m) }; let f = fn(r) { match r { Ok(v) if v > 10 => \"big\", Ok(v) => v, Err(m) => m } }; [f(Ok(20)), f(Ok(1)), f(Err(\"e\"))]", "[big,1,e]"},
+		{"enum Shape { Circle(r), Rect(w, h) }; match Rect(2, 3) { Circle(_) => 0, Rect(w, h) => w * h }", "6"},
+		{"enum R { Ok(v), Err(m) }; match Ok([1, 2]) { Ok([a, b]) => a + b, Err | Ok => 0 }", "3"},
+		{"enum Option { Some(v), None }; let Some(x) = Some(5); x", "5"},
+		{"enum Option { Some(v), None }; let f = fn(o) { match o { Some(Some(x)) => x, Some(None) | None => 0 } }; f(Some(Some(4))) + f(Some(None))", "4"},
+		{"let xs = [1, 2]; xs.push(3, 4); xs", "[1,2,3,4]"},
+		{"let xs = [1, 2, 3]; [xs.pop(), xs]", "[3,[1,2]]"},
+		{"[1, 2, 3, 4].map(fn(x) { x * 2 }).filter(fn(x) { x > 4 }).reduce(fn(acc, x) { acc + x }, 0)", "14"},
+		{"[1, 2, 3].filter(fn(x) { x % 2 })", "[1,3]"},
+		{`["ab", "c"].map(len)`, "[2,1]"},
+		{`[1, 2, 3].reverse().join(", ")`, "3, 2, 1"},
+		{"[1, 2].contains(2.0)", "true"},
+		{`"Hello".upper() + "World".lower()`, "HELLOworld"},
+		{`" a ".trim().len()`, "1"},
+		{`"a,b".split(",")`, "[a,b]"},
+		{`["abc".starts_with("ab"), "abc".ends_with("x"), "abc".contains("b")]`, "[true,false,true]"},
+		{`["ab".repeat(3), "ab".repeat(0), "".repeat(5)]`, "[ababab,,]"},
+		{"let r = random(); let n = random(3); [r >= 0 && r < 1, n >= 0 && n < 3, now() > 1600000000000]", "[true,true,true]"},
+		{`let m = {"b": 2, "a": 1}; m.delete("b"); [m.keys(), m.values(), m.has("a"), m.len()]`, "[[a],[1],true,1]"},
+		{"let m = {2 ** 64: 1}; m[2 ** 64] = 2; m[1 << 64] += 1; [m[2 ** 64], m.len(), m.has(2 ** 64), m.has(2 ** 65)]", "[3,1,true,false]"},
+		{"let m = {2 ** 64: 1}; m[2 ** 64] = 2; [m.delete(2 ** 64), m.len()]", "[2,0]"},
+		{"let m = {}; for i in 0..5000 { m[2 ** 64 + i] = i; m[2 ** 64 + i] += 1 }; m.delete(2 ** 64); [m.len(), m[2 ** 64 + 4999], m.keys()[0] + 1]", "[4999,5000,18446744073709551618]"},
+		{`let m = {"len": fn() { 42 }}; m.len()`, "42"},
+		{"math.max(1, 5)", "5"},
+		{"let double = fn(x) { x * 2 }; 3.double().double()", "12"},
+		{"let add = fn(a, b) { a + b }; 1.add(b: 2)", "3"},
+		{"let f = fn() { let inc = fn(x) { x + 1 }; 1.inc() }; f()", "2"},
+		{`"a".print()`, "nil"},
+		{"let s = 0; for x in [1, 2, 3] { s += x }; s", "6"},
+		{`let s = ""; for c in "abc" { s = c + s }; s`, "cba"},
+		{`let s = ""; for [k, v] in {"b": 2, "a": 1} { s += k + v }; s`, "a1b2"},
+		{"let it = [1].iter(); let a = it.next(); let b = it.next(); [a.value, a.done, b.value, b.done]", "[1,false,nil,true]"},
+		{"let gen = fn(n) { yield n; yield n * 2 }; gen(3).to_array()", "[3,6]"},
+		{"let gen = fn(xs) { for x in xs { if x % 2 { yield x } } }; let s = 0; for x in gen([1, 2, 3, 4, 5]) { s += x }; s", "9"},
+		{"let nat = fn(i) { yield i; for x in nat(i + 1) { yield x } }; nat(0).map(fn(x) { x * x }).filter(fn(x) { x % 2 }).take(3).to_array()", "[1,9,25]"},
+		{"let nat = fn(i) { yield i; for x in nat(i + 1) { yield x } }; [nat(1).contains(5), iter([1, 2]).reduce(fn(a, b) { a + b }, 0)]", "[true,3]"},
+		{`let gen = fn() { yield "a"; yield "b" }; gen().join("-")`, "a-b"},
+		{"let first = fn(xs) { for x in xs { return x }; nil }; let nat = fn(i) { yield i; for x in nat(i + 1) { yield x } }; first(nat(7))", "7"},
+		{"let gen = fn() { yield 1; return 5; yield 2 }; gen().to_array()", "[1]"},
+		{"let gen = fn() { yield 1 }; gen()", "generator iterator"},
+		{"struct R { n, fn each(self) { for x in [1, 2] { yield x * self.n } } }; R(10).each().to_array()", "[10,20]"},
+		{"[0..3, 1..=3, (0..10).step(3)]", "[0..3,1..=3,(0..10).step(3)]"},
+		{"[(0..5).to_array(), (1..=5).to_array(), (10..0).step(-3).to_array(), (3..1).to_array()]", "[[0,1,2,3,4],[1,2,3,4,5],[10,7,4,1],[]]"},
+		{"[len(0..5), len(0..=5), (0..10).step(3).len(), len(5..5), len(5..=5), len(0..1000000000000)]", "[5,6,4,0,1,1000000000000]"},
+		{"let r = (0..10).step(2); [r[0], r[4], r[-1]]", "[0,8,8]"},
+		{"let r = (0..=10).step(5); [r.contains(5), r.contains(10), r.contains(3), r.contains(10.0), (0..10).contains(10)]", "[true,true,false,true,false]"},
+		{"let s = 0; for i in 1..=100 { s += i }; s", "5050"},
+		{"let n = 4; (0..n - 1).map(fn(x) { x * x }).filter(fn(x) { x > 0 }).to_array()", "[1,4]"},
+		{"(0..1000000000000).take(3).to_array()", "[0,1,2]"},
+		{`[1, 2, 3, 4] |> filter(fn(x) { x % 2 == 0 }) |> map(fn(x) { x * 10 }) |> join(",")`, "20,40"},
+		{"let double = fn(x) { x * 2 }; [3 |> double, 3 |> double(), 1 + 2 |> double, [1, 2] |> len() > 1]", "[6,6,6,true]"},
+		{"let sub = fn(a, b) { a - b }; let map = fn(xs, f) { 0 }; [5 |> sub(2), [1] |> map(sub)]", "[3,0]"},
+		{"let inc = fn(x) { x + 1 }; let double = fn(x) { x * 2 }; [(inc >> double)(1), (double >> inc)(1), compose(inc, double, inc)(1), 1 |> inc >> double, 8 >> 1]", "[4,3,5,4,4]"},
+		{"struct P { x }; let f = fn(a, b) { a + b } >> P; f(1, 2).x", "3"},
+		{"let inc = fn(x) { x + 1 }; (0..3).map(inc >> inc).to_array()", "[2,3,4]"},
+		{"[[1, 2].map(|x| x * 2), [1, 2].map(x => x + 1), (|| 7)(), (|a, b = 10| a + b)(1)]", "[[2,4],[2,3],7,11]"},
+		{"[1, 2, 3] |> filter(x => x % 2) |> map(|x| { let y = x * 3; y }) |> reduce(|a, b| a + b, 0)", "12"},
+		{"let gen = |n| { yield n; yield n + 1 }; gen(5).to_array()", "[5,6]"},
+		{"let ok = true; match 3 { n if ok => n * 2, _ => 0 }", "6"},
+		{"let f = |[a, b], {c}| a + b + c; f([1, 2], {\"c\": 3})", "6"},
+		{"let f = fn() { let x = 1 }; let g = fn() { for x in [1] { x } }; [f(), g(), fn() { if true { 3 } }()]", "[nil,nil,3]"},
+		{"let square = fn(x) { x * x }; (1..=4).map(|i| spawn square(i)).to_array().map(|t| await t)", "[1,4,9,16]"},
+		{"let n = 0; let bump = fn() { n += 1; n }; let t = spawn bump(); [await t, await t, n]", "[1,1,0]"},
+		{"let xs = [1]; let t = spawn fn() { xs.push(2); xs }(); [await t, xs]", "[[1,2],[1]]"},
+		{"let ch = channel(3); ch.send(1); ch.send([2]); [len(ch), ch.recv(), ch.recv(), ch]", "[2,1,[2],channel(3)]"},
+		{"let ch = channel(2); ch.send(1); ch.close(); [ch.is_closed(), ch.recv(), ch.recv(), ch.recv()]", "[true,1,nil,nil]"},
+		{"let xs = [1]; let ch = channel(1); ch.send(xs); xs.push(2); ch.recv()", "[1]"},
+		{"let jobs = channel(); let out = channel(10); let work = fn() { for j in jobs { out.send(j * 2) } }; let ws = [spawn work(), spawn work()]; for i in 0..5 { jobs.send(i) }; jobs.close(); ws.map(|w| await w); out.close(); out.iter().reduce(|a, b| a + b, 0)", "20"},
+		{"let a = channel(); let b = channel(1); b.send(2); select { x in a => x, x in b => x * 10 }", "20"},
+		{"let a = channel(); select { x in a => x, _ => \"idle\" }", "idle"},
+		{"let out = channel(1); [select { out.send(1) => \"sent\", _ => \"full\" }, select { out.send(2) => \"sent\", _ => \"full\" }, out.recv()]", "[sent,full,1]"},
+		{"let ch = channel(1); ch.send([1, 2]); select { [a, b] in ch => a + b }", "3"},
+		{"enum Msg { Add(n), Stop }; let ch = channel(3); let sum = fn() { let s = 0; for m in ch { match m { Add(n) => { s += n }, Stop => { return s } } } }; let t = spawn sum(); ch.send(Add(2)); ch.send(Add(3)); ch.send(Stop); [await t, await spawn fn() { Stop }() == Stop]", "[5,true]"},
+		{"let gen = fn() { yield 1; yield 2 }; let it = gen(); let t = spawn fn() { gen().to_array() }(); [await t, it.next().value]", "[[1,2],1]"},
 	}
 
 	for i, test := range ts {
@@ -144,6 +371,137 @@ func TestEvalArithmetic(t *testing.T) {
 			if obj.Inspect() != test.e {
 				t.Errorf("expected %q, got %q\n", test.e, obj.Inspect())
 			}
+
+			if obj, ok := EvaluateResolved(t, test.i); ok && obj.Inspect() != test.e {
+				t.Errorf("expected %q with resolved variables, got %q\n", test.e, obj.Inspect())
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	type tt struct {
+		i   string
+		err string
+	}
+
+	ts := []tt{
+		{"1 / 0", "zero division"},
+		{"1 % 0", "zero division"},
+		{"1.5 % 0", "zero division"},
+		{"1 << -1", "negative shift count"},
+		{`~"a"`, "bitwise not is supported only for int types"},
+		{`fn() { "abc" }()[0] = "x"`, "expression is not assignable"},
+		{`let s = "abc"; s[3] = "x"`, "index out of bounds"},
+		{`let s = "abc"; s[0] = "xy"`, "must contains only one character"},
+		{`b[0][1] = 1`, "identifier is not defined"},
+		{"[1, 2, 3][-4]", "index out of bounds"},
+		{"[1, 2, 3][3]", "index out of bounds"},
+		{`[1, 2][""]`, "unexpected index type"},
+		{`[1, 2]["a":]`, "slice bounds must be integers"},
+		{`let m = {}; m[1:2]`, "slicing is supported only for arrays and strings"},
+		{`match 3 { 1 => "one", 2 => "two" }`, "no match arm for value 3"},
+		{"let [a, b] = [1]", "value [1] does not match pattern [a, b]"},
+		{"let f = fn(x, step = 1) { x }; f()", "missing argument for parameter x"},
+		{"const x = 1; x = 2", "can not assign to constant x"},
+		{"y = 1", "identifier y is not defined"},
+		{"let f = fn() { y = 1 }; f()", "identifier y is not defined"},
+		{"if true { let y = 2 }; y", "identifier is not defined"},
+		{"let f = fn(x) { let x = 1 }; f(2)", "identifier is already defined"},
+		{"const x = 1; x += 2", "can not assign to constant x"},
+		{"const [a, b] = [1, 2]; b = 3", "can not assign to constant b"},
+		{"const x = 1; let f = fn() { x = 2 }; f()", "can not assign to constant x"},
+		{"const x = 1; let x = 2", "identifier is already defined"},
+		{"let arr = freeze([1, 2]); arr[0] = 3", "can not modify frozen array"},
+		{"let m = freeze({\"a\": 1}); m[\"b\"] = 2", "can not modify frozen map"},
+		{"let m = {\"a\": 1}; let alias = m; freeze(m); alias[\"a\"] += 1", "can not modify frozen map"},
+		{"math[\"abs\"] = 1", "can not modify frozen map"},
+		{"let f = fn(x) { x }; f(1, 2)", "unexpected argument 2"},
+		{"let f = fn(x) { x }; f(y: 1, x: 2)", "unexpected named argument y"},
+		{"let f = fn(x) { x }; f(1, x: 2)", "argument x is given more than once"},
+		{"let f = fn(x) { x }; f(x: 1, x: 2)", "argument x is given more than once"},
+		{"let f = fn(x) { x }; f(...1)", "spread argument must be an array, got INTEGER"},
+		{"len(x: [1])", "build in function does not accept named argument x"},
+		{"let [a, ...b] = 1", "value 1 does not match pattern [a, ...b]"},
+		{`let {name} = {"age": 1}`, "does not match pattern {name}"},
+		{"let [a, a] = [1, 2]", "identifier is already defined"},
+		{"let f = fn([a]) { a }; f([1, 2])", "value [1,2] does not match pattern [a]"},
+		{"struct P { x }; P(1).y", "struct P has no field y"},
+		{"struct P { x }; let p = P(1); p.y = 2", "struct P has no field y"},
+		{"struct P { x, y }; P(1)", "missing field y of struct P"},
+		{"struct P { x }; P(1, 2)", "struct P has 1 fields, got 2 arguments"},
+		{"struct P { x }; P{z: 1}", "struct P has no field z"},
+		{"struct P { x }; P{x: 1, x: 2}", "field x is given more than once"},
+		{"struct P { x }; P(1, x: 2)", "field x is given more than once"},
+		{"struct P { x }; let p = freeze(P(1)); p.x = 2", "can not modify frozen struct"},
+		{"let m = freeze({}); m.a = 1", "can not modify frozen map"},
+		{"let n = 1; n.x", "can not access field x of INTEGER"},
+		{"let s = \"a\"; s.x = 1", "can not assign field x of STRING"},
+		{"let Q = 1; Q{x: 1}", "Q is not a struct"},
+		{"struct P { x }; struct P { y }", "identifier is already defined"},
+		{"[1].nope()", "ARRAY has no method nope"},
+		{"struct P { x }; P(1).nope()", "struct P has no method nope"},
+		{"enum R { Ok(v) }; Ok(1, 2)", "variant Ok has 1 fields, got 2 arguments"},
+		{"enum R { Ok(v) }; Ok()", "missing field v of variant Ok"},
+		{"enum R { Ok(v) }; Ok(1).x", "variant Ok has no field x"},
+		{"enum R { Ok(v) }; R.Err", "enum R has no variant Err"},
+		{"enum R { Ok(v) }; match Ok(1) { Ok(a, b) => 1 }", "variant Ok has 1 fields, pattern has 2"},
+		{"enum R { Ok(v), Err(m) }; let Ok(x) = Err(1)", "value Err(1) does not match pattern Ok(x)"},
+		{"enum R { A }; enum S { A }", "identifier is already defined"},
+		{"enum R { Ok(v) }; Ok(1) + 1", "not supported types"},
+		{"for x in 1 { x }", "can not iterate over INTEGER"},
+		{"for [a, b] in [1] { a }", "value 1 does not match pattern [a, b]"},
+		{"let gen = fn() { yield 1; 1 / 0 }; for x in gen() { x }", "zero division | 0 line 0, column 31"},
+		{"let gen = fn() { yield 1 }; gen().take(\"a\")", "take: expected integer, got STRING"},
+		{"let gen = fn() { for x in g { yield x } }; let g = gen(); g.next()", "generator is already running"},
+		{"let x = 1; 2.x()", "INTEGER has no method x"},
+		{"0..1.5", "range bounds must be integers, got INTEGER and FLOAT"},
+		{"(0..3)[3]", "index out of bounds"},
+		{"(0..3).step(0)", "step: step must not be zero"},
+		{"1 |> 2", "expected function expression"},
+		{"1 |> nothing()", "INTEGER has no method nothing"},
+		{"compose(fn(x) { x }, 1)", "can not compose INTEGER"},
+		{"let f = fn(x) { x } >> fn(x) { x / 0 }; f(1)", "zero division"},
+		{"await 1", "can not await INTEGER"},
+		{`"a".repeat(-1)`, "repeat: expected non negative integer, got -1"},
+		{"random(0)", "expected positive integer, got 0"},
+		{"exit(256)", "exit code must be an integer from 0 to 255, got 256"},
+		{"is_error()", "expected 1 argument, got 0"},
+		{"now(1)", "expected 0 arguments, got 1"},
+		{`"ab".repeat(9223372036854775807)`, "repeat: repeated string is too long"},
+		{"let t = spawn fn() { 1 / 0 }(); await t", "zero division | 0 line 0, column 26"},
+		{"select { x in 1 => x }", "expected channel, got INTEGER"},
+		{"let ch = channel(1); ch.close(); ch.send(1)", "send: can not send to closed channel"},
+		{"let ch = channel(1); ch.close(); select { ch.send(1) => 1 }", "can not send to closed channel"},
+		{"let ch = channel(1); ch.close(); ch.close()", "close: channel is already closed"},
+		{"channel(-1)", "buffer size must be a non negative integer, got -1"},
+		{"channel(1).send()", "send: expected 1 arguments, got 0"},
+		{"let it = [1].iter(); let t = spawn fn() { it.next() }(); await t", "next: can not use array iterator of another task"},
+		{"freeze([1]).push(2)", "push: can not modify frozen array"},
+		{"[].pop()", "pop: can not pop from empty array"},
+		{"[1].pop(1)", "pop: expected 0 arguments, got 1"},
+		{"[1].map(fn(x) { x / 0 })", "zero division"},
+		{"[1].map(f: 1)", "build in method does not accept named argument f"},
+		{`[1].filter(fn(x) { "s" })`, "filter: can not use STRING as condition"},
+		{"freeze({\"a\": 1}).delete(\"a\")", "delete: can not modify frozen map"},
+	}
+
+	for i, test := range ts {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			p := parser.NewParser(bytes.NewBufferString(test.i))
+			root, err := p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(p.Errors) != 0 {
+				t.Fatal(p.Errors)
+			}
+
+			_, err = NewEvaluator().Eval(root)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error %q, got %v\n", test.err, err)
+			}
 		})
 	}
 }
@@ -224,19 +582,19 @@ func TestEval(t *testing.T) {
 		{
 			`fn (b, c) {}`,
 			object.FuncObject{
-				Args: []parser.IdentifierExpression{
-					{
+				Args: []parser.Parameter{
+					{Pattern: parser.IdentifierExpression{
 						Identifier: lexer.Token{
 							Token:   lexer.IDENT,
 							Literal: "b",
 						},
-					},
-					{
+					}},
+					{Pattern: parser.IdentifierExpression{
 						Identifier: lexer.Token{
 							Token:   lexer.IDENT,
 							Literal: "c",
 						},
-					},
+					}},
 				},
 				Body: parser.BlockStatement{},
 			},
@@ -244,19 +602,19 @@ func TestEval(t *testing.T) {
 		{
 			`let a = fn (b, c) {}`,
 			object.FuncObject{
-				Args: []parser.IdentifierExpression{
-					{
+				Args: []parser.Parameter{
+					{Pattern: parser.IdentifierExpression{
 						Identifier: lexer.Token{
 							Token:   lexer.IDENT,
 							Literal: "b",
 						},
-					},
-					{
+					}},
+					{Pattern: parser.IdentifierExpression{
 						Identifier: lexer.Token{
 							Token:   lexer.IDENT,
 							Literal: "c",
 						},
-					},
+					}},
 				},
 				Body: parser.BlockStatement{},
 			},
@@ -392,7 +750,7 @@ func TestEval(t *testing.T) {
 		},
 		{
 			`let a = { "a": "b"}`,
-			object.MapObject{
+			&object.MapObject{
 				Val: map[object.Object]object.Object{
 					object.StringObject{
 						Val: "a",
@@ -494,6 +852,71 @@ func TestEval(t *testing.T) {
 				Val: "day 1",
 			},
 		},
+		{
+			`let a = 10; a += 5; a -= 1; a *= 2; a /= 4; a %= 4; a`,
+			object.IntegerObject{
+				Val: 3,
+			},
+		},
+		{
+			`let a = 6; a &= 3; a |= 8; a <<= 2; a >>= 1; a`,
+			object.IntegerObject{
+				Val: 20,
+			},
+		},
+		{
+			`let s = "a"; s += "b"; s`,
+			object.StringObject{
+				Val: "ab",
+			},
+		},
+		{
+			`let cache = {}; cache["n"] = 1; cache["n"] += 1; cache["n"]`,
+			object.IntegerObject{
+				Val: 2,
+			},
+		},
+		{
+			`let calls = 0
+			let key = fn() { calls += 1; return 0 }
+			let a = [1]
+			a[key()] += 5;
+			[a[0], calls]`,
+			&object.ArrayObject{
+				Val: []object.Object{
+					object.IntegerObject{
+						Val: 6,
+					},
+					object.IntegerObject{
+						Val: 1,
+					},
+				},
+			},
+		},
+		{
+			`let grid = [[0, 0], [0, 0]]; let i = 1; grid[i][0] = 5; grid[1][0] += 1; grid[1][0]`,
+			object.IntegerObject{
+				Val: 6,
+			},
+		},
+		{
+			`let obj = {"a": {}}; obj["a"]["b"] = 1; obj["a"]["b"]`,
+			object.IntegerObject{
+				Val: 1,
+			},
+		},
+		{
+			`let arr = [1, 2]; let f = fn() { arr }; f()[0] = 10; arr[0]`,
+			object.IntegerObject{
+				Val: 10,
+			},
+		},
+		{
+			`let m = {"s": ["abc"]}; m["s"][0][2] = "x"; m["s"][0]`,
+			object.StringObject{
+				Val: "abx",
+			},
+		},
 	}
 
 	for i, test := range ts {
@@ -505,3 +928,424 @@ func TestEval(t *testing.T) {
 	}
 
 }
+
+func TestGeneratorsDoNotLeak(t *testing.T) {
+	ts := []string{
+		// consumers stopping early close the generator
+		"let nat = fn(i) { yield i; for x in nat(i + 1) { yield x } }; nat(0).take(10).to_array()",
+		"let nat = fn(i) { yield i; for x in nat(i + 1) { yield x } }; let f = fn() { for x in nat(0) { if x > 3 { return x } } }; f()",
+		// abandoned generators are closed when Eval returns
+		"let nat = fn(i) { yield i; for x in nat(i + 1) { yield x } }; let it = nat(0); it.next(); it.next()",
+		"let gen = fn() { yield 1; yield 2 }; let it = gen(); it.next(); 1 / 0",
+	}
+
+	before := runtime.NumGoroutine()
+	for _, test := range ts {
+		root, err := parser.NewParser(bytes.NewBufferString(test)).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		NewEvaluator().Eval(root)
+	}
+
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 100 {
+			t.Fatalf("expected %d goroutines, got %d", before, runtime.NumGoroutine())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTasksDoNotLeak(t *testing.T) {
+	ts := []string{
+		"let square = fn(x) { x * x }; [spawn square(1), spawn square(2)].map(|t| await t)",
+		// tasks waiting for channels and other tasks stop when Eval returns
+		"let ch = channel(); let t = spawn fn() { ch.recv() }(); spawn fn() { await t }(); 1",
+		"let ch = channel(); spawn fn() { for x in ch { x } }(); spawn fn() { ch.send(1); ch.send(2) }(); 1",
+		"let ch = channel(); spawn fn() { select { x in ch => x } }(); 1",
+	}
+
+	before := runtime.NumGoroutine()
+	for _, test := range ts {
+		root, err := parser.NewParser(bytes.NewBufferString(test)).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewEvaluator().Eval(root); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 100 {
+			t.Fatalf("expected %d goroutines, got %d", before, runtime.NumGoroutine())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEvalContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	ts := []struct {
+		in      string
+		ctx     context.Context
+		err     error
+		message string
+	}{
+		{"let f = fn() { 1 }; f()", canceled, context.Canceled, "evaluation is canceled: context canceled | f() line 0, column 22"},
+		{"for x in [1, 2] { x }", canceled, context.Canceled, "line 0, column 3"},
+		{"let n = 0; for x in 0..9223372036854775807 { n += 1 }", nil, context.DeadlineExceeded, "evaluation is canceled: context deadline exceeded"},
+		{"let f = fn(n) { f(n + 1) + 1 }; f(0)", nil, context.DeadlineExceeded, "f((n + 1))"},
+		{"let ch = channel(); ch.recv()", nil, context.DeadlineExceeded, "ch.recv() line 0, column 28"},
+		{"let ch = channel(); select { x in ch => x }", nil, context.DeadlineExceeded, "line 0, column 26"},
+		{"let t = spawn fn() { for x in 0..9223372036854775807 { x } }(); await t", nil, context.DeadlineExceeded, "await t"},
+		{"let ch = channel(); spawn fn() { ch.send(1) }(); ch.iter().map(|x| x).to_array()", nil, context.DeadlineExceeded, "to_array()"},
+		{"(0..9223372036854775807).iter().contains(-1)", nil, context.DeadlineExceeded, "contains(-(1))"},
+		{"(0..9223372036854775807).to_array()", nil, context.DeadlineExceeded, "to_array()"},
+		{"(0..9223372036854775807).join(\",\")", nil, context.DeadlineExceeded, "join(\",\")"},
+	}
+
+	for i, test := range ts {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			root, err := parser.NewParser(bytes.NewBufferString(test.in)).Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := test.ctx
+			if ctx == nil {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+				defer cancel()
+			}
+
+			_, err = NewEvaluator().EvalContext(ctx, root)
+			if !errors.As(err, &CanceledError{}) || !errors.Is(err, test.err) {
+				t.Fatalf("expected canceled error, got %v", err)
+			}
+
+			if !strings.Contains(err.Error(), test.message) {
+				t.Fatalf("expected error containing %q, got %q", test.message, err)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	ts := []struct {
+		in      string
+		opt     Option
+		limit   object.Limit
+		message string
+	}{
+		{"let n = 0; for x in 0..1000 { n += x }", WithMaxSteps(100), object.StepsLimit, "steps limit of 100 exceeded"},
+		{"let f = fn() { f() }; f()", WithMaxCallDepth(1000), object.CallDepthLimit, "call depth limit of 1000 exceeded | f() line 0, column 17"},
+		{"let f = fn(n) { f(n + 1) }; f(0)", WithTypeGuards(), object.CallDepthLimit, "call depth limit of 10000 exceeded"},
+		{"let f = fn(n) { if n == 0 { return 0 }; f(n - 1) }; [1].map(|x| f(100))", WithMaxCallDepth(50), object.CallDepthLimit, "call depth limit of 50 exceeded"},
+		{"let s = \"ab\"; for x in 0..10 { s = s + s }", WithMaxStringLen(100), object.StringLimit, "string length limit of 100 exceeded | (s + s)"},
+		{"\"ab\".repeat(1000)", WithMaxStringLen(100), object.StringLimit, "string length limit of 100 exceeded | \"ab\".repeat(1000)"},
+		{"(0..1000).join(\",\")", WithMaxStringLen(100), object.StringLimit, "string length limit of 100 exceeded | (0 .. 1000).join"},
+		{"let xs = []; for x in 0..10 { xs.push(x) }", WithMaxArrayLen(5), object.ArrayLimit, "array length limit of 5 exceeded | xs.push(x)"},
+		{"[1, 2, 3]", WithMaxArrayLen(2), object.ArrayLimit, "array length limit of 2 exceeded | [1,2,3]"},
+		{"let nat = fn() { let i = 0; for x in 0..9223372036854775807 { yield x } }; nat().to_array()", WithMaxArrayLen(1000), object.ArrayLimit, "array length limit of 1000 exceeded | nat().to_array()"},
+		{"let m = {}; for x in 0..10 { m[x] = x }", WithMaxMapLen(3), object.MapLimit, "map size limit of 3 exceeded | m[x]"},
+		{"let m = {1: 1}; m[1] = 2; m[1] = 3; let n = {1: 1, 2: 2}", WithMaxMapLen(1), object.MapLimit, "map size limit of 1 exceeded"},
+		{"let m = {}; m.a = 1; m.b = 2; m.a = 3; m.c = 3", WithMaxMapLen(2), object.MapLimit, "map size limit of 2 exceeded | m.c"},
+		{"let xs = []; for x in 0..10000 { xs.push(\"abc\" + x) }", WithMaxAlloc(10000), object.AllocLimit, "allocation limit of 10000 exceeded"},
+		{"2 ** 30000000", WithMaxAlloc(1000), object.AllocLimit, "allocation limit of 1000 exceeded | (2 ** 30000000)"},
+		{"1 << 300000000", WithMaxAlloc(1000), object.AllocLimit, "allocation limit of 1000 exceeded | (1 << 300000000)"},
+		{"(2 ** 100) ** 1000000", WithMaxAlloc(1000), object.AllocLimit, "allocation limit of 1000 exceeded"},
+		{"(2 ** 100) << 300000000", WithMaxAlloc(1000), object.AllocLimit, "allocation limit of 1000 exceeded"},
+		{`math["pow"](2, 100000000)`, WithMaxAlloc(1 << 20), object.AllocLimit, "allocation limit of 1048576 exceeded | math[\"pow\"](2,100000000)"},
+		{"let x = 3; for i in 0..26 { x = x * x }", WithMaxAlloc(1 << 20), object.AllocLimit, "allocation limit of 1048576 exceeded | (x * x)"},
+		{"let x = 2 ** 4000000; let y = x * x", WithMaxAlloc(1 << 20), object.AllocLimit, "allocation limit of 1048576 exceeded | (x * x)"},
+		{"let x = 1 << 4000000; let y = x + x", WithMaxAlloc(1 << 19), object.AllocLimit, "allocation limit of 524288 exceeded | (x + x)"},
+		{"let t = spawn fn() { let n = 0; for x in 0..1000 { n += x } }(); await t", WithMaxSteps(100), object.StepsLimit, "steps limit of 100 exceeded"},
+	}
+
+	for i, test := range ts {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			root, err := parser.NewParser(bytes.NewBufferString(test.in)).Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = NewEvaluator(test.opt).Eval(root)
+			var limit LimitError
+			if !errors.As(err, &limit) || limit.Err.Limit != test.limit {
+				t.Fatalf("expected %s limit error, got %v", test.limit, err)
+			}
+
+			if !strings.Contains(err.Error(), test.message) {
+				t.Fatalf("expected error containing %q, got %q", test.message, err)
+			}
+		})
+	}
+
+	// limits apply to every evaluation separately
+	root, err := parser.NewParser(bytes.NewBufferString("let n = 0; for x in 0..10 { n += x }; n")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewEvaluator(WithMaxSteps(200), WithMaxCallDepth(10), WithMaxStringLen(10), WithMaxArrayLen(10), WithMaxMapLen(10), WithMaxAlloc(1000))
+	for i := 0; i < 3; i++ {
+		res, err := e.Eval(root)
+		if err != nil || res.Inspect() != "45" {
+			t.Fatalf("expected 45, got %v %v", res, err)
+		}
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	ts := []struct {
+		in         string
+		granted    object.Capability
+		capability object.Capability
+		message    string
+	}{
+		{"print(1)", 0, object.Stdout, "permission denied: stdout is not allowed | print(1) line 0, column 6"},
+		{"let p = print; [1].map(p)", object.Clock, object.Stdout, "permission denied: stdout is not allowed | [1].map(p)"},
+		{"let f = fn() { random() }; await spawn f()", object.Stdout, object.Random, "permission denied: random is not allowed | random()"},
+		{"now()", object.Stdout | object.Random, object.Clock, "permission denied: clock is not allowed"},
+	}
+
+	for i, test := range ts {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			root, err := parser.NewParser(bytes.NewBufferString(test.in)).Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = NewEvaluator(WithCapabilities(object.Capabilities{Granted: test.granted})).Eval(root)
+			var permission PermissionError
+			if !errors.As(err, &permission) || permission.Err.Capability != test.capability {
+				t.Fatalf("expected %s permission error, got %v", test.capability, err)
+			}
+
+			if !strings.Contains(err.Error(), test.message) {
+				t.Fatalf("expected error containing %q, got %q", test.message, err)
+			}
+		})
+	}
+}
+
+func TestIO(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+	out := filepath.Join(dir, "out")
+	for _, d := range []string{data, out} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(data, "in.txt"), []byte("a,1;b,2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// links lead out of the roots, the second one to the missing file
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(data, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(filepath.Join(outside, "missing"), filepath.Join(out, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("YAMI_TEST_ENV", "set")
+	caps := object.Capabilities{Granted: object.AllCapabilities, ReadRoot: dir, WriteRoot: out}
+	ts := []struct {
+		in       string
+		expected string
+	}{
+		{`read_file("DATA/in.txt").split(";").map(|l| l.split(",")[1])`, "[1,2]"},
+		{`let r = read_file("DATA/missing.txt"); [is_error(r), r.message, is_error("s")]`, "[true,can not read DATA/missing.txt: no such file or directory,false]"},
+		{`[list_dir("DATA"), exists("DATA/in.txt"), exists("DATA/missing.txt")]`, "[[in.txt,link],true,false]"},
+		{`list_dir("DATA/missing")`, "error(can not list DATA/missing: no such file or directory)"},
+		{`[write_file("OUT/r.txt", "a"), append_file("OUT/r.txt", "b"), append_file("OUT/r.txt", "c"), read_file("OUT/r.txt")]`, "[nil,nil,nil,abc]"},
+		{`write_file("OUT/r.txt", "x"); read_file("OUT/r.txt")`, "x"},
+		{`is_error(write_file("OUT/missing/r.txt", "x"))`, "true"},
+		{`[env("YAMI_TEST_ENV"), env("YAMI_TEST_MISSING")]`, "[set,nil]"},
+		{`[read_line(), read_lines(), read_line(), read_lines()]`, "[first,[second,,third],nil,[]]"},
+		{`args()`, "[-v,file.txt]"},
+		{`let t = spawn fn() { read_file("DATA/missing.txt") }(); (await t).message.starts_with("can not read")`, "true"},
+	}
+
+	for i, test := range ts {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			in := strings.NewReplacer("DATA", data, "OUT", out).Replace(test.in)
+			root, err := parser.NewParser(bytes.NewBufferString(in)).Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e := NewEvaluator(WithCapabilities(caps), WithStdin(strings.NewReader("first\nsecond\n\nthird")), WithArgs("-v", "file.txt"))
+			res, err := e.Eval(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if expected := strings.NewReplacer("DATA", data).Replace(test.expected); res.Inspect() != expected {
+				t.Fatalf("expected %s, got %s", expected, res.Inspect())
+			}
+		})
+	}
+
+	denied := []struct {
+		in   string
+		caps object.Capabilities
+	}{
+		{`read_file("DATA/../../secret")`, caps},
+		{`list_dir("DATA/link")`, caps},
+		{`exists("DATA/link/missing")`, caps},
+		{`write_file("DATA/w.txt", "x")`, caps},
+		{`write_file("OUT/link", "x")`, caps},
+		{`read_file("DATA/in.txt")`, object.Capabilities{Granted: object.DefaultCapabilities}},
+		{`read_line()`, object.Capabilities{Granted: object.DefaultCapabilities}},
+		{`env("HOME")`, object.Capabilities{Granted: object.DefaultCapabilities}},
+	}
+
+	for i, test := range denied {
+		t.Run(fmt.Sprintf("denied %d", i), func(t *testing.T) {
+			in := strings.NewReplacer("DATA", data, "OUT", out).Replace(test.in)
+			root, err := parser.NewParser(bytes.NewBufferString(in)).Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = NewEvaluator(WithCapabilities(test.caps)).Eval(root)
+			if !errors.As(err, &PermissionError{}) {
+				t.Fatalf("expected permission error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExit(t *testing.T) {
+	ts := []struct {
+		in   string
+		code int
+	}{
+		{"exit()", 0},
+		{"let f = fn() { for x in 0..10 { if x == 3 { exit(x) } } }; f(); 1", 3},
+		{"[1, 2].map(|x| exit(x + 1))", 2},
+		{"let g = fn() { yield 1; exit(4) }; g().to_array()", 4},
+		{"await spawn fn() { exit(5) }()", 5},
+	}
+
+	for i, test := range ts {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			root, err := parser.NewParser(bytes.NewBufferString(test.in)).Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = NewEvaluator().Eval(root)
+			var exit *object.ExitError
+			if !errors.As(err, &exit) || exit.Code != test.code {
+				t.Fatalf("expected exit with code %d, got %v", test.code, err)
+			}
+		})
+	}
+}
+
+func TestTypeGuards(t *testing.T) {
+	type tt struct {
+		i   string
+		err string
+	}
+
+	ts := []tt{
+		{"let f = fn(x: int) { x }; f(1)", ""},
+		{"let f = fn(x: float, y: int | nil = nil) { x }; f(1)", ""},
+		{"let f = fn(xs: array<int>) -> map<string, int> { let m = {\"n\": len(xs)}; m }; f([1, 2])", ""},
+		{"let f = fn(x: int) { x }; f(\"a\")", "argument x expected int, got string"},
+		{"let f = fn(x: int) -> string { x }; f(1)", "result expected string, got int"},
+		{"let f = fn(xs: array<int>) { xs }; f([1, \"a\"])", "argument xs expected array<int>, got array<any>"},
+		{"let f = fn(...xs: array<string>) { xs }; f(\"a\", 1)", "argument xs expected array<string>, got array<any>"},
+		{"struct P { x: int }; let f = fn(p: P) -> int { p.x }; f(P(1))", ""},
+		{"struct P { x: int }; P(\"a\")", "field x expected int, got string"},
+		{"struct P { x: int }; let p = P(1); p.x = nil", "field x expected int, got nil"},
+		{"struct P { x }; struct Q { x }; let f = fn(p: P) { p }; f(Q(1))", "argument p expected P, got Q"},
+		{"enum Option { Some(v: int), None }; let f = fn(o: Option) -> Option { o }; f(None); f(Some(1))", ""},
+		{"enum Option { Some(v: int), None }; Some(\"a\")", "field v expected int, got string"},
+		{"enum R { Ok(v) }; enum Option { None }; let f = fn(o: Option) { o }; f(Ok(1))", "argument o expected Option, got R"},
+	}
+
+	for i, test := range ts {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			root, err := parser.NewParser(bytes.NewBufferString(test.i)).Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := NewEvaluator().Eval(root); err != nil {
+				t.Fatalf("annotations must be ignored without type guards, got %v", err)
+			}
+
+			_, err = NewEvaluator(WithTypeGuards()).Eval(root)
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+				t.Errorf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func benchmarkProgram(b *testing.B, in string) {
+	for _, resolved := range []bool{false, true} {
+		name := "by_name"
+		if resolved {
+			name = "resolved"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			root, err := parser.NewParser(bytes.NewBufferString(in)).Parse()
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			if resolved && resolver.HasErrors(resolver.New().Resolve(root).Diagnostics) {
+				b.Fatal("unexpected resolver errors")
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := NewEvaluator().Eval(root); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFib(b *testing.B) {
+	benchmarkProgram(b, `
+let fib = fn(n) {
+	if n < 2 { return n }
+	fib(n - 1) + fib(n - 2)
+}
+fib(20)`)
+}
+
+func BenchmarkRecursion(b *testing.B) {
+	benchmarkProgram(b, `
+let sum = fn(n, acc) {
+	let step = fn(x) { x + n }
+	if n == 0 { return acc }
+	{
+		let next = n - 1
+		sum(next, step(acc))
+	}
+}
+sum(3000, 0)`)
+}
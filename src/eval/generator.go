@@ -0,0 +1,241 @@
+package eval
+
+import (
+	"errors"
+	"github.com/charkpep/yami/src/object"
+	"github.com/charkpep/yami/src/parser"
+	"runtime"
+	"sync"
+)
+
+// errGeneratorClosed unwinds the body of the generator closed while it is suspended
+var errGeneratorClosed = errors.New("generator is closed")
+
+type yielded struct {
+	val object.Object
+	// done is set by the last message of the generator, err is the error of the body if any
+	done bool
+	err  error
+}
+
+// generator runs the body of the generator function on its own goroutine, which only runs while the consumer
+// waits for the next value, so the body and the consumer never run at the same time
+type generator struct {
+	resume  chan struct{}
+	yields  chan yielded
+	stop    chan struct{}
+	once    sync.Once
+	started bool
+	// mu guards running and closing, close is called by finalizers and Eval on other goroutines
+	mu      sync.Mutex
+	running bool
+	// closing is set when the body closes its own iterator, it is stopped once the consumer gets the value
+	closing bool
+	done    bool
+	owner   *generators
+}
+
+// generators suspended generators created by Eval, the ones not closed by their consumers are closed when
+// Eval returns, as the suspended body may keep its own iterator reachable
+type generators struct {
+	mu  sync.Mutex
+	set map[*generator]struct{}
+}
+
+func newGenerators() *generators {
+	return &generators{
+		set: make(map[*generator]struct{}),
+	}
+}
+
+func (gs *generators) add(g *generator) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.set[g] = struct{}{}
+}
+
+func (gs *generators) remove(g *generator) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	delete(gs.set, g)
+}
+
+func (gs *generators) closeAll() {
+	gs.mu.Lock()
+	open := make([]*generator, 0, len(gs.set))
+	for g := range gs.set {
+		open = append(open, g)
+	}
+	gs.mu.Unlock()
+
+	for _, g := range open {
+		g.close()
+	}
+}
+
+// generate returns the iterator over the values yielded by the function, the body starts on the first call
+// of next. Iterators abandoned without Close stop the goroutine when they are garbage collected or when Eval
+// returns.
+func (e Evaluator) generate(fn object.FuncObject, env *object.Environment) *object.IteratorObject {
+	g := &generator{
+		resume: make(chan struct{}),
+		yields: make(chan yielded),
+		stop:   make(chan struct{}),
+		owner:  e.generators,
+	}
+
+	if g.owner != nil {
+		g.owner.add(g)
+	}
+
+	e.gen = g
+	run := func() {
+		select {
+		case <-g.resume:
+		case <-g.stop:
+			return
+		}
+
+		_, err := e.evalStatements(fn.Body.Statements, env)
+		if errors.Is(err, errGeneratorClosed) {
+			return
+		}
+
+		// the consumer waits while the body runs
+		g.yields <- yielded{done: true, err: err}
+	}
+
+	it := object.NewIterator("generator", func() (object.Object, bool, error) {
+		if g.done {
+			return nil, false, nil
+		}
+
+		g.mu.Lock()
+		if g.running {
+			g.mu.Unlock()
+			return nil, false, errors.New("generator is already running")
+		}
+
+		select {
+		case <-g.stop:
+			// closed by the finalizer or Eval, the body does not run anymore
+			g.mu.Unlock()
+			return nil, false, nil
+		default:
+		}
+
+		g.running = true
+		g.mu.Unlock()
+		if !g.started {
+			g.started = true
+			go run()
+		}
+
+		g.resume <- struct{}{}
+		res := <-g.yields
+		g.mu.Lock()
+		g.running = false
+		closing := g.closing
+		g.mu.Unlock()
+		if closing {
+			g.close()
+		}
+
+		if res.done {
+			g.done = true
+			g.close()
+			return nil, false, res.err
+		}
+
+		return res.val, true, nil
+	}, g.close)
+
+	// the goroutine does not refer to the iterator, so the iterator can be collected while the body is suspended
+	runtime.SetFinalizer(it, func(*object.IteratorObject) { g.close() })
+	return it
+}
+
+func (g *generator) close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.running {
+		g.closing = true
+		return
+	}
+
+	g.once.Do(func() {
+		close(g.stop)
+		if g.owner != nil {
+			g.owner.remove(g)
+		}
+	})
+}
+
+// yield passes the value to the consumer and suspends the body until the next value is requested
+func (g *generator) yield(val object.Object) error {
+	g.yields <- yielded{val: val}
+	select {
+	case <-g.resume:
+		return nil
+	case <-g.stop:
+		return errGeneratorClosed
+	}
+}
+
+// evalFor binds the values of the iterator to the pattern in the scope of each iteration, the iterator is
+// closed when the loop ends, e.g. by return
+func (e Evaluator) evalFor(stmt parser.ForStatement, env *object.Environment) (object.Object, error) {
+	iterable, err := e.eval(stmt.Iterable, env)
+	if err != nil {
+		return nil, err
+	}
+
+	it, err := e.iterate(iterable)
+	if err != nil {
+		return nil, NewRuntimeError(err.Error(), stmt.Iterable)
+	}
+
+	defer it.Close()
+	for {
+		if err := e.canceled(stmt); err != nil {
+			return nil, err
+		}
+
+		val, ok, err := it.Next()
+		if err != nil {
+			return nil, e.iteratorError(err, stmt.Iterable)
+		}
+
+		if !ok {
+			return object.NIL, nil
+		}
+
+		iterEnv := object.DeriveEnv(env)
+		if err := e.bindPattern(stmt.Pattern, val, iterEnv); err != nil {
+			return nil, err
+		}
+
+		res, err := e.evalBlockStatement(stmt.Body, iterEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		if res.Type() == object.RETURN_OBJ {
+			return res, nil
+		}
+	}
+}
+
+// iteratorError reports errors of the iterator at the node consuming it, errors of the evaluated code,
+// e.g. the body of the generator, keep their position
+func (e Evaluator) iteratorError(err error, node parser.Node) error {
+	if isStopped(err) {
+		return e.unwind(err, node)
+	}
+
+	if errors.As(err, &RuntimeError{}) {
+		return err
+	}
+
+	return NewRuntimeError(err.Error(), node)
+}
@@ -0,0 +1,173 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charkpep/yami/src/object"
+	"github.com/charkpep/yami/src/parser"
+)
+
+// evalSpawn starts the task evaluating the call, arguments included, on its own goroutine. The task gets
+// the copy of the scope, so it shares only channels and tasks with the rest of the program, its result is
+// copied as well.
+func (e Evaluator) evalSpawn(spawn parser.SpawnExpression, env *object.Environment) (object.Object, error) {
+	taskEnv, err := object.NewCopier().Env(env)
+	if err != nil {
+		return nil, NewRuntimeError(err.Error(), spawn)
+	}
+
+	task := e
+	task.gen = nil
+	// generators of the task can not be shared, they are closed when the task ends
+	task.generators = newGenerators()
+	return object.NewTask(func() (object.Object, error) {
+		defer task.generators.closeAll()
+		res, err := task.evalCallExpression(spawn.Call, taskEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err = object.NewCopier().Object(res)
+		if err != nil {
+			return nil, NewRuntimeError(err.Error(), spawn)
+		}
+
+		return res, nil
+	}), nil
+}
+
+// evalAwait waits for the task, errors of the task are returned as they are
+func (e Evaluator) evalAwait(await parser.AwaitExpression, env *object.Environment) (object.Object, error) {
+	val, err := e.eval(await.Task, env)
+	if err != nil {
+		return nil, err
+	}
+
+	task, ok := val.(*object.TaskObject)
+	if !ok {
+		return nil, NewRuntimeError(fmt.Sprintf("can not await %s", val.Type()), await)
+	}
+
+	res, err := task.Await(e.ctx.Done())
+	if err != nil {
+		return nil, e.iteratorError(err, await)
+	}
+
+	return res, nil
+}
+
+// evalSelect evaluates the channels and the values to send in the order of the arms, then waits until one
+// of the operations can proceed and evaluates its arm. The default arm is evaluated if none of them is ready.
+func (e Evaluator) evalSelect(sel parser.SelectExpression, env *object.Environment) (object.Object, error) {
+	cases := make([]object.SelectCase, 0, len(sel.Arms))
+	arms := make([]parser.SelectArm, 0, len(sel.Arms))
+	var fallback *parser.SelectArm
+	for i, arm := range sel.Arms {
+		if arm.Default() {
+			fallback = &sel.Arms[i]
+			continue
+		}
+
+		val, err := e.eval(arm.Channel, env)
+		if err != nil {
+			return nil, err
+		}
+
+		ch, ok := val.(*object.ChannelObject)
+		if !ok {
+			return nil, NewRuntimeError(fmt.Sprintf("expected channel, got %s", val.Type()), arm.Channel)
+		}
+
+		c := object.SelectCase{Chan: ch}
+		if arm.Send != nil {
+			if c.Send, err = e.eval(arm.Send, env); err != nil {
+				return nil, err
+			}
+		}
+
+		cases = append(cases, c)
+		arms = append(arms, arm)
+	}
+
+	i, val, _, err := object.Select(cases, fallback != nil, e.ctx.Done())
+	if err != nil {
+		return nil, e.iteratorError(err, sel)
+	}
+
+	armEnv := object.DeriveEnv(env)
+	if i < 0 {
+		return e.evalBlockStatement(fallback.Body, armEnv)
+	}
+
+	if arms[i].Pattern != nil {
+		if err := e.bindPattern(arms[i].Pattern, val, armEnv); err != nil {
+			return nil, err
+		}
+	}
+
+	return e.evalBlockStatement(arms[i].Body, armEnv)
+}
+
+// channelMethod calls the method of the channel, false if there is no such method. Unlike the build in methods
+// of the other types, they wait for other tasks, so they are interrupted when the evaluation stops.
+func (e Evaluator) channelMethod(ch *object.ChannelObject, name string, args []object.Object) (object.Object, bool, error) {
+	expected := map[string]int{"send": 1, "recv": 0, "close": 0, "len": 0, "is_closed": 0, "iter": 0}
+	n, ok := expected[name]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if len(args) != n {
+		return nil, true, fmt.Errorf("expected %d arguments, got %d", n, len(args))
+	}
+
+	switch name {
+	case "send":
+		return object.NIL, true, ch.Send(args[0], e.ctx.Done())
+	case "recv":
+		val, _, err := ch.Recv(e.ctx.Done())
+		return val, true, err
+	case "close":
+		return object.NIL, true, ch.Close()
+	case "len":
+		return object.IntegerObject{Val: int64(ch.Len())}, true, nil
+	case "is_closed":
+		return object.BoolObject{Val: ch.IsClosed()}, true, nil
+	default:
+		return ch.Iter(e.ctx.Done()), true, nil
+	}
+}
+
+// iterate returns the iterator over the value, iterators over channels are interrupted when the evaluation stops
+func (e Evaluator) iterate(val object.Object) (*object.IteratorObject, error) {
+	if ch, ok := val.(*object.ChannelObject); ok {
+		return ch.Iter(e.ctx.Done()), nil
+	}
+
+	return object.Iterate(val)
+}
+
+// unwind returns the error stopping the evaluation, channel operations and awaits interrupted by the context
+// are canceled at the node, limits exceeded and permissions denied by build in functions are reported at the
+// node as well
+func (e Evaluator) unwind(err error, node parser.Node) error {
+	if errors.Is(err, object.ErrStopped) {
+		return NewCanceledError(e.ctx.Err(), node)
+	}
+
+	var permission *object.PermissionError
+	if errors.As(err, &permission) && !errors.As(err, &PermissionError{}) {
+		return NewPermissionError(permission, node)
+	}
+
+	return limitError(err, node)
+}
+
+// isStopped reports whether the error unwinds the evaluation rather than reports the failure
+func isStopped(err error) bool {
+	var limit *object.LimitError
+	var permission *object.PermissionError
+	var exit *object.ExitError
+	return errors.Is(err, errGeneratorClosed) || errors.Is(err, object.ErrStopped) || errors.As(err, &CanceledError{}) ||
+		errors.As(err, &limit) || errors.As(err, &permission) || errors.As(err, &exit)
+}
@@ -39,24 +39,88 @@ func (l *Lexer) Read(t *Token) error {
 	l.column += 1
 	switch cur {
 	case '*':
-		l.assignToken(t, ASTERISK, l.line, l.column, "*")
+		switch {
+		case l.peekAndAssert(byte('*')):
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, DASTERISK, l.line, l.column, "**")
+		case l.peekAndAssert(byte('=')):
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, ASTERISK_ASSIGN, l.line, l.column, "*=")
+		default:
+			l.assignToken(t, ASTERISK, l.line, l.column, "*")
+		}
+
 		return nil
-	case '=':
+	case '%':
 		ok := l.peekAndAssert(byte('='))
 		if !ok {
+			l.assignToken(t, PERCENT, l.line, l.column, "%")
+			return nil
+		}
+
+		l.r.ReadByte()
+		l.column++
+		l.assignToken(t, PERCENT_ASSIGN, l.line, l.column, "%=")
+		return nil
+	case '^':
+		l.assignToken(t, BXOR, l.line, l.column, "^")
+		return nil
+	case '~':
+		l.assignToken(t, BNOT, l.line, l.column, "~")
+		return nil
+	case '=':
+		switch {
+		case l.peekAndAssert(byte('=')):
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, EQ, l.line, l.column, "==")
+		case l.peekAndAssert(byte('>')):
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, FATARROW, l.line, l.column, "=>")
+		default:
 			l.assignToken(t, ASSIGN, l.line, l.column, "=")
+		}
+
+		return nil
+	case '.':
+		ok := l.peekAndAssert(byte('.'))
+		if !ok {
+			l.assignToken(t, DOT, l.line, l.column, ".")
 			return nil
 		}
 
 		l.r.ReadByte()
 		l.column++
-		l.assignToken(t, EQ, l.line, l.column, "==")
+		switch {
+		case l.peekAndAssert(byte('=')):
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, DOTDOTEQ, l.line, l.column, "..=")
+		case l.peekAndAssert(byte('.')):
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, ELLIPSIS, l.line, l.column, "...")
+		default:
+			l.assignToken(t, DOTDOT, l.line, l.column, "..")
+		}
+
 		return nil
 	case ';':
 		l.assignToken(t, SCOLON, l.line, l.column, ";")
 		return nil
 	case '+':
-		l.assignToken(t, PLUS, l.line, l.column, "+")
+		ok := l.peekAndAssert(byte('='))
+		if !ok {
+			l.assignToken(t, PLUS, l.line, l.column, "+")
+			return nil
+		}
+
+		l.r.ReadByte()
+		l.column++
+		l.assignToken(t, PLUS_ASSIGN, l.line, l.column, "+=")
 		return nil
 	case '>':
 		switch {
@@ -67,6 +131,13 @@ func (l *Lexer) Read(t *Token) error {
 		case l.peekAndAssert(byte('>')):
 			l.r.ReadByte()
 			l.column++
+			if l.peekAndAssert(byte('=')) {
+				l.r.ReadByte()
+				l.column++
+				l.assignToken(t, BRSHIFT_ASSIGN, l.line, l.column, ">>=")
+				break
+			}
+
 			l.assignToken(t, BRSHIFT, l.line, l.column, ">>")
 		default:
 			l.assignToken(t, GT, l.line, l.column, ">")
@@ -82,6 +153,13 @@ func (l *Lexer) Read(t *Token) error {
 		case l.peekAndAssert(byte('<')):
 			l.r.ReadByte()
 			l.column++
+			if l.peekAndAssert(byte('=')) {
+				l.r.ReadByte()
+				l.column++
+				l.assignToken(t, BLSHIFT_ASSIGN, l.line, l.column, "<<=")
+				break
+			}
+
 			l.assignToken(t, BLSHIFT, l.line, l.column, "<<")
 		default:
 			l.assignToken(t, LT, l.line, l.column, "<")
@@ -89,7 +167,22 @@ func (l *Lexer) Read(t *Token) error {
 
 		return nil
 	case '-':
-		l.assignToken(t, HYPHEN, l.line, l.column, "-")
+		if l.peekAndAssert(byte('>')) {
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, ARROW, l.line, l.column, "->")
+			return nil
+		}
+
+		ok := l.peekAndAssert(byte('='))
+		if !ok {
+			l.assignToken(t, HYPHEN, l.line, l.column, "-")
+			return nil
+		}
+
+		l.r.ReadByte()
+		l.column++
+		l.assignToken(t, HYPHEN_ASSIGN, l.line, l.column, "-=")
 		return nil
 	case '!':
 		ok := l.peekAndAssert(byte('='))
@@ -103,27 +196,47 @@ func (l *Lexer) Read(t *Token) error {
 		l.assignToken(t, NEQ, l.line, l.column, "!=")
 		return nil
 	case '&':
-		ok := l.peekAndAssert(byte('&'))
-		if !ok {
+		switch {
+		case l.peekAndAssert(byte('&')):
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, AND, l.line, l.column, "&&")
+		case l.peekAndAssert(byte('=')):
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, BAND_ASSIGN, l.line, l.column, "&=")
+		default:
 			l.assignToken(t, BAND, l.line, l.column, "&")
-			return nil
 		}
 
-		l.r.ReadByte()
-		l.column++
-		l.assignToken(t, AND, l.line, l.column, "&&")
 		return nil
 	case '|':
-		ok := l.peekAndAssert(byte('|'))
-		if !ok {
+		switch {
+		case l.peekAndAssert(byte('|')):
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, OR, l.line, l.column, "||")
+		case l.peekAndAssert(byte('=')):
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, BOR_ASSIGN, l.line, l.column, "|=")
+		case l.peekAndAssert(byte('>')):
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, PIPE, l.line, l.column, "|>")
+		default:
 			l.assignToken(t, BOR, l.line, l.column, "|")
-			return nil
 		}
-		l.r.ReadByte()
-		l.column++
-		l.assignToken(t, OR, l.line, l.column, "||")
+
 		return nil
 	case '/':
+		if l.peekAndAssert(byte('=')) {
+			l.r.ReadByte()
+			l.column++
+			l.assignToken(t, SLASH_ASSIGN, l.line, l.column, "/=")
+			return nil
+		}
+
 		ok := l.peekAndAssert(byte('/'))
 		if !ok {
 			l.assignToken(t, SLASH, l.line, l.column, "/")
@@ -178,6 +291,13 @@ func (l *Lexer) Read(t *Token) error {
 		var literal []byte
 		if IsDigit(cur) {
 			literal = l.readNumber()
+			if fraction := l.readFraction(); fraction != nil {
+				literal = append(literal, fraction...)
+				l.column += len(literal)
+				l.assignToken(t, FLOAT, l.line, l.column, string(cur)+string(literal))
+				return nil
+			}
+
 			l.column += len(literal)
 			l.assignToken(t, NUMBER, l.line, l.column, string(cur)+string(literal))
 			return nil
@@ -232,6 +352,18 @@ func (l *Lexer) readNumber() []byte {
 	return buf
 }
 
+// readFraction reads the fractional part of a float literal, dot included. The dot is consumed only
+// when it is followed by a digit, so nil is returned for anything else.
+func (l *Lexer) readFraction() []byte {
+	peek, err := l.r.Peek(2)
+	if err != nil || peek[0] != '.' || !IsDigit(peek[1]) {
+		return nil
+	}
+
+	l.r.ReadByte()
+	return append([]byte{'.'}, l.readNumber()...)
+}
+
 func (l *Lexer) skipWhitespace() {
 	for peek, err := l.r.Peek(1); err == nil && IsWhitespace(peek[0]); peek, err = l.r.Peek(1) {
 		l.column++
@@ -27,6 +27,202 @@ func TestSingeToken(t *testing.T) {
 				Literal: ";",
 			},
 		},
+		{
+			i: "10.25",
+			out: Token{
+				Token:   FLOAT,
+				Literal: "10.25",
+			},
+		},
+		{
+			i: "**",
+			out: Token{
+				Token:   DASTERISK,
+				Literal: "**",
+			},
+		},
+		{
+			i: "%",
+			out: Token{
+				Token:   PERCENT,
+				Literal: "%",
+			},
+		},
+		{
+			i: "^",
+			out: Token{
+				Token:   BXOR,
+				Literal: "^",
+			},
+		},
+		{
+			i: "~",
+			out: Token{
+				Token:   BNOT,
+				Literal: "~",
+			},
+		},
+		{
+			i: "=>",
+			out: Token{
+				Token:   FATARROW,
+				Literal: "=>",
+			},
+		},
+		{
+			i: "..",
+			out: Token{
+				Token:   DOTDOT,
+				Literal: "..",
+			},
+		},
+		{
+			i: "..=",
+			out: Token{
+				Token:   DOTDOTEQ,
+				Literal: "..=",
+			},
+		},
+		{
+			i: "...",
+			out: Token{
+				Token:   ELLIPSIS,
+				Literal: "...",
+			},
+		},
+		{
+			i: ".",
+			out: Token{
+				Token:   DOT,
+				Literal: ".",
+			},
+		},
+		{
+			i: "match",
+			out: Token{
+				Token:   MATCH,
+				Literal: "match",
+			},
+		},
+		{
+			i: "->",
+			out: Token{
+				Token:   ARROW,
+				Literal: "->",
+			},
+		},
+		{
+			i: "nil",
+			out: Token{
+				Token:   NIL,
+				Literal: "nil",
+			},
+		},
+		{
+			i: "const",
+			out: Token{
+				Token:   CONST,
+				Literal: "const",
+			},
+		},
+		{
+			i: "struct",
+			out: Token{
+				Token:   STRUCT,
+				Literal: "struct",
+			},
+		},
+		{
+			i: "enum",
+			out: Token{
+				Token:   ENUM,
+				Literal: "enum",
+			},
+		},
+		{
+			i: "yield",
+			out: Token{
+				Token:   YIELD,
+				Literal: "yield",
+			},
+		},
+		{
+			i: "spawn",
+			out: Token{
+				Token:   SPAWN,
+				Literal: "spawn",
+			},
+		},
+		{
+			i: "|>",
+			out: Token{
+				Token:   PIPE,
+				Literal: "|>",
+			},
+		},
+		{
+			i: "+=",
+			out: Token{
+				Token:   PLUS_ASSIGN,
+				Literal: "+=",
+			},
+		},
+		{
+			i: "-=",
+			out: Token{
+				Token:   HYPHEN_ASSIGN,
+				Literal: "-=",
+			},
+		},
+		{
+			i: "*=",
+			out: Token{
+				Token:   ASTERISK_ASSIGN,
+				Literal: "*=",
+			},
+		},
+		{
+			i: "/=",
+			out: Token{
+				Token:   SLASH_ASSIGN,
+				Literal: "/=",
+			},
+		},
+		{
+			i: "%=",
+			out: Token{
+				Token:   PERCENT_ASSIGN,
+				Literal: "%=",
+			},
+		},
+		{
+			i: "&=",
+			out: Token{
+				Token:   BAND_ASSIGN,
+				Literal: "&=",
+			},
+		},
+		{
+			i: "|=",
+			out: Token{
+				Token:   BOR_ASSIGN,
+				Literal: "|=",
+			},
+		},
+		{
+			i: "<<=",
+			out: Token{
+				Token:   BLSHIFT_ASSIGN,
+				Literal: "<<=",
+			},
+		},
+		{
+			i: ">>=",
+			out: Token{
+				Token:   BRSHIFT_ASSIGN,
+				Literal: ">>=",
+			},
+		},
 	}
 
 	for _, test := range ts {
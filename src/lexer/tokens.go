@@ -13,23 +13,54 @@ const (
 	// Basic syntax
 
 	LET    = "LET"
+	CONST  = "CONST"
 	FUNC   = "FUNC"
 	IDENT  = "INDENT"
 	NUMBER = "NUMBER"
+	FLOAT  = "FLOAT"
 	STRING = "STRING"
 	IF     = "IF"
 	ELSE   = "ELSE"
 	TRUE   = "TRUE"
 	FALSE  = "FALSE"
 	RETURN = "RETURN"
-
-	PLUS   = "PLUS"
-	HYPHEN = "HYPHEN"
-	SLASH  = "SLASH"
-
-	SCOLON = "SCOLON" // Semi colon
-	COLON  = "COLON"
-	ASSIGN = "ASSIGN"
+	MATCH  = "MATCH"
+	STRUCT = "STRUCT"
+	ENUM   = "ENUM"
+	FOR    = "FOR"
+	IN     = "IN"
+	YIELD  = "YIELD"
+	SPAWN  = "SPAWN"
+	AWAIT  = "AWAIT"
+	SELECT = "SELECT"
+
+	PLUS    = "PLUS"
+	HYPHEN  = "HYPHEN"
+	SLASH   = "SLASH"
+	PERCENT = "PERCENT"
+
+	SCOLON   = "SCOLON" // Semi colon
+	COLON    = "COLON"
+	ASSIGN   = "ASSIGN"
+	FATARROW = "FATARROW" // =>
+	ARROW    = "ARROW"    // -> return type
+	DOT      = "DOT"
+	DOTDOT   = "DOTDOT"   // .. exclusive range
+	DOTDOTEQ = "DOTDOTEQ" // ..= inclusive range
+	ELLIPSIS = "ELLIPSIS" // ... rest element
+	PIPE     = "PIPE"     // |> passes the value to the call
+
+	// Compound assignment
+
+	PLUS_ASSIGN     = "PLUS_ASSIGN"
+	HYPHEN_ASSIGN   = "HYPHEN_ASSIGN"
+	ASTERISK_ASSIGN = "ASTERISK_ASSIGN"
+	SLASH_ASSIGN    = "SLASH_ASSIGN"
+	PERCENT_ASSIGN  = "PERCENT_ASSIGN"
+	BAND_ASSIGN     = "BAND_ASSIGN"
+	BOR_ASSIGN      = "BOR_ASSIGN"
+	BLSHIFT_ASSIGN  = "BLSHIFT_ASSIGN"
+	BRSHIFT_ASSIGN  = "BRSHIFT_ASSIGN"
 
 	// Boolean
 
@@ -45,22 +76,23 @@ const (
 
 	// Bitwise operation
 
-	BAND = "BAND"
-	BOR  = "BOR"
-	// TODO: implement the rest of bitwise operators
+	BAND    = "BAND"
+	BOR     = "BOR"
 	BXOR    = "BXOR"
+	BNOT    = "BNOT"
 	BLSHIFT = "BLSHIFT"
 	BRSHIFT = "BRSHIFT"
 
 	// Binary
 
-	BLEFT    = "BLEFT"   // [B]rackets left
-	BRIGHT   = "BRIGHT"  // [B]rackets right
-	SBLEFT   = "SBLEFT"  // [S]quare [B]rackets left
-	SBRIGHT  = "SBRIGHT" // [S]quare [B]rackets right
-	BRLEFT   = "BRLEFT"  // Curly [Br]aces left
-	BRRIGHT  = "BRRIGHT" // Curly [Br]aces left
-	ASTERISK = "ASTERISK"
+	BLEFT     = "BLEFT"   // [B]rackets left
+	BRIGHT    = "BRIGHT"  // [B]rackets right
+	SBLEFT    = "SBLEFT"  // [S]quare [B]rackets left
+	SBRIGHT   = "SBRIGHT" // [S]quare [B]rackets right
+	BRLEFT    = "BRLEFT"  // Curly [Br]aces left
+	BRRIGHT   = "BRRIGHT" // Curly [Br]aces left
+	ASTERISK  = "ASTERISK"
+	DASTERISK = "DASTERISK" // [D]ouble asterisk, power
 
 	COMA    = "COMA"
 	ILLEGAL = "ILLEGAL"
@@ -71,11 +103,22 @@ const (
 var keywords = map[string]TokenType{
 	"fn":     FUNC,
 	"let":    LET,
+	"const":  CONST,
+	"nil":    NIL,
 	"if":     IF,
 	"else":   ELSE,
 	"true":   TRUE,
 	"false":  FALSE,
 	"return": RETURN,
+	"match":  MATCH,
+	"struct": STRUCT,
+	"enum":   ENUM,
+	"for":    FOR,
+	"in":     IN,
+	"yield":  YIELD,
+	"spawn":  SPAWN,
+	"await":  AWAIT,
+	"select": SELECT,
 	"<<":     BLEFT,
 	">>":     BRIGHT,
 }
@@ -0,0 +1,130 @@
+package object
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Capability resource of the host used by build in functions, capabilities are combined with |
+type Capability uint
+
+const (
+	Stdout Capability = 1 << iota
+	Stdin
+	FileRead
+	FileWrite
+	EnvRead
+	Clock
+	Random
+)
+
+var capabilityNames = []string{"stdout", "stdin", "read", "write", "env", "clock", "random"}
+
+// DefaultCapabilities granted to programs unless the host sets its own, they do not expose files, the environment
+// or the input of the host
+const DefaultCapabilities = Stdout | Clock | Random
+
+// AllCapabilities grants every resource of the host
+const AllCapabilities = Stdout | Stdin | FileRead | FileWrite | EnvRead | Clock | Random
+
+func (c Capability) String() string {
+	var names []string
+	for i, name := range capabilityNames {
+		if c&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// Capabilities resources of the host the program can use. Files are read only under ReadRoot and written only
+// under WriteRoot, empty roots do not restrict the paths.
+type Capabilities struct {
+	Granted   Capability
+	ReadRoot  string
+	WriteRoot string
+}
+
+// PermissionError the build in function needs the capability which is not granted, Path is set when the path
+// is outside the root of the capability
+type PermissionError struct {
+	Capability Capability
+	Path       string
+}
+
+func (pe *PermissionError) Error() string {
+	if pe.Path != "" {
+		return fmt.Sprintf("permission denied: %s of %s is not allowed", pe.Capability, pe.Path)
+	}
+
+	return fmt.Sprintf("permission denied: %s is not allowed", pe.Capability)
+}
+
+// Require checks the capabilities are granted
+func (c Capabilities) Require(need Capability) error {
+	if missing := need &^ c.Granted; missing != 0 {
+		return &PermissionError{Capability: missing}
+	}
+
+	return nil
+}
+
+// Path returns the absolute path the program reads with FileRead or writes with FileWrite, paths outside the root
+// of the capability are denied. Symbolic links are followed, so they can not lead out of the root.
+func (c Capabilities) Path(need Capability, path string) (string, error) {
+	if err := c.Require(need); err != nil {
+		return "", err
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	root := c.ReadRoot
+	if need == FileWrite {
+		root = c.WriteRoot
+	}
+
+	if root == "" {
+		return abs, nil
+	}
+
+	if root, err = filepath.Abs(root); err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(resolveLinks(root, 0), resolveLinks(abs, 0))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &PermissionError{Capability: need, Path: path}
+	}
+
+	return abs, nil
+}
+
+// resolveLinks follows symbolic links of the longest existing part of the path. Links to missing files are
+// followed as well, files created through them must stay under the root too.
+func resolveLinks(path string, depth int) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+
+	// cyclic links can not be opened anyway
+	if target, err := os.Readlink(path); err == nil && depth < 255 {
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+
+		return resolveLinks(target, depth+1)
+	}
+
+	dir := filepath.Dir(path)
+	if dir == path {
+		return path
+	}
+
+	return filepath.Join(resolveLinks(dir, depth), filepath.Base(path))
+}
@@ -0,0 +1,205 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	CHANNEL_OBJ ObjectType = "CHANNEL"
+	TASK_OBJ    ObjectType = "TASK"
+)
+
+// ErrStopped is returned by the channel operations and await interrupted by the stop channel
+var ErrStopped = errors.New("evaluation is stopped")
+
+// ChannelObject passes values between tasks. Send blocks while the buffer is full and receive while it is
+// empty, the receivers of the closed channel get the values left in the buffer and then nil. Values are
+// copied when they are sent, so the receiver never shares them with the sender.
+type ChannelObject struct {
+	values chan Object
+	closed chan struct{}
+	once   sync.Once
+}
+
+// NewChannel creates the channel buffering size values, the channel without the buffer passes the value
+// only when both the sender and the receiver are ready
+func NewChannel(size int) *ChannelObject {
+	return &ChannelObject{
+		values: make(chan Object, size),
+		closed: make(chan struct{}),
+	}
+}
+
+func (ch *ChannelObject) Type() ObjectType {
+	return CHANNEL_OBJ
+}
+
+func (ch *ChannelObject) Inspect() string {
+	return fmt.Sprintf("channel(%d)", cap(ch.values))
+}
+
+// Len returns the number of the buffered values
+func (ch *ChannelObject) Len() int {
+	return len(ch.values)
+}
+
+// IsClosed reports whether the channel is closed
+func (ch *ChannelObject) IsClosed() bool {
+	select {
+	case <-ch.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close closes the channel, waiting receivers get nil and senders an error
+func (ch *ChannelObject) Close() error {
+	closed := false
+	ch.once.Do(func() {
+		close(ch.closed)
+		closed = true
+	})
+
+	if !closed {
+		return errors.New("channel is already closed")
+	}
+
+	return nil
+}
+
+// Send passes the copy of the value to the channel, it blocks until there is a place in the buffer or
+// a receiver. Stop interrupts waiting, nil stop never does.
+func (ch *ChannelObject) Send(val Object, stop <-chan struct{}) error {
+	_, _, _, err := Select([]SelectCase{{Chan: ch, Send: val}}, false, stop)
+	return err
+}
+
+// Recv returns the next value of the channel, false if the channel is closed and there are no values left
+func (ch *ChannelObject) Recv(stop <-chan struct{}) (Object, bool, error) {
+	_, val, ok, err := Select([]SelectCase{{Chan: ch}}, false, stop)
+	return val, ok, err
+}
+
+// Iter returns the iterator over the values received from the channel until it is closed
+func (ch *ChannelObject) Iter(stop <-chan struct{}) *IteratorObject {
+	return NewIterator("channel", func() (Object, bool, error) {
+		return ch.Recv(stop)
+	}, nil)
+}
+
+// SelectCase channel operation of Select, values are sent if Send is set, otherwise received
+type SelectCase struct {
+	Chan *ChannelObject
+	Send Object
+}
+
+// Select waits until one of the cases can proceed and returns its index, the received value and false if it
+// was received from the closed channel. Closed channels are always ready, so receiving arms get nil and
+// sending ones an error. When no case is ready, -1 is returned if withDefault is set.
+func Select(cases []SelectCase, withDefault bool, stop <-chan struct{}) (int, Object, bool, error) {
+	for _, c := range cases {
+		if c.Send != nil && c.Chan.IsClosed() {
+			return -1, nil, false, errors.New("can not send to closed channel")
+		}
+	}
+
+	// every case waits for the value and for closing of the channel, the last ones are stop and default
+	selects := make([]reflect.SelectCase, 0, 2*len(cases)+2)
+	for _, c := range cases {
+		if c.Send != nil {
+			val, err := NewCopier().Object(c.Send)
+			if err != nil {
+				return -1, nil, false, err
+			}
+
+			selects = append(selects, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(c.Chan.values), Send: reflect.ValueOf(&val).Elem()})
+		} else {
+			selects = append(selects, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.Chan.values)})
+		}
+
+		selects = append(selects, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.Chan.closed)})
+	}
+
+	selects = append(selects, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(stop)})
+	if withDefault {
+		selects = append(selects, reflect.SelectCase{Dir: reflect.SelectDefault})
+	}
+
+	chosen, recv, _ := reflect.Select(selects)
+	switch {
+	case chosen == 2*len(cases):
+		return -1, nil, false, ErrStopped
+	case chosen > 2*len(cases):
+		return -1, nil, false, nil
+	}
+
+	i := chosen / 2
+	c := cases[i]
+	if chosen%2 == 0 {
+		if c.Send != nil {
+			return i, nil, true, nil
+		}
+
+		return i, recv.Interface().(Object), true, nil
+	}
+
+	if c.Send != nil {
+		return i, nil, false, errors.New("can not send to closed channel")
+	}
+
+	// values sent before closing are received first
+	select {
+	case val := <-c.Chan.values:
+		return i, val, true, nil
+	default:
+		return i, NIL, false, nil
+	}
+}
+
+// TaskObject function call running on its own goroutine, await waits for its result
+type TaskObject struct {
+	done chan struct{}
+	val  Object
+	err  error
+}
+
+// NewTask runs the function on a new goroutine
+func NewTask(run func() (Object, error)) *TaskObject {
+	t := &TaskObject{
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(t.done)
+		t.val, t.err = run()
+	}()
+
+	return t
+}
+
+func (t *TaskObject) Type() ObjectType {
+	return TASK_OBJ
+}
+
+func (t *TaskObject) Inspect() string {
+	select {
+	case <-t.done:
+		return "task (done)"
+	default:
+		return "task (running)"
+	}
+}
+
+// Await waits until the task finishes and returns its result or error, stop interrupts waiting
+func (t *TaskObject) Await(stop <-chan struct{}) (Object, error) {
+	select {
+	case <-t.done:
+		return t.val, t.err
+	case <-stop:
+		return nil, ErrStopped
+	}
+}
@@ -0,0 +1,201 @@
+package object
+
+import "fmt"
+
+// Copier copies values passed between tasks. Arrays, maps, structs and enums are copied with their elements,
+// functions with the scopes they refer to and declarations with their methods, so tasks never share mutable
+// values. Channels, tasks and immutable values are shared. Iterators can not be shared, their copies fail
+// when they are used. Values referred more than once are copied once.
+type Copier struct {
+	copies map[any]any
+}
+
+func NewCopier() *Copier {
+	return &Copier{
+		copies: make(map[any]any),
+	}
+}
+
+func (c *Copier) Object(obj Object) (Object, error) {
+	switch v := obj.(type) {
+	case nil, IntegerObject, BigIntObject, FloatObject, BoolObject, NilObject, StringObject, ErrorObject, BuildInFunc,
+		*RangeObject, *ChannelObject, *TaskObject:
+		return obj, nil
+	case *ArrayObject:
+		if cp, ok := c.copies[v]; ok {
+			return cp.(*ArrayObject), nil
+		}
+
+		arr := &ArrayObject{Val: make([]Object, len(v.Val)), Frozen: v.Frozen}
+		c.copies[v] = arr
+		for i, elem := range v.Val {
+			var err error
+			if arr.Val[i], err = c.Object(elem); err != nil {
+				return nil, err
+			}
+		}
+
+		return arr, nil
+	case *MapObject:
+		if cp, ok := c.copies[v]; ok {
+			return cp.(*MapObject), nil
+		}
+
+		mp := &MapObject{Val: make(map[Object]Object, len(v.Val)), Frozen: v.Frozen}
+		c.copies[v] = mp
+		for key, val := range v.Val {
+			cp, err := c.Object(val)
+			if err != nil {
+				return nil, err
+			}
+
+			mp.Val[key] = cp
+		}
+
+		return mp, nil
+	case *StructObject:
+		if cp, ok := c.copies[v]; ok {
+			return cp.(*StructObject), nil
+		}
+
+		st := &StructObject{Val: make([]Object, len(v.Val)), Frozen: v.Frozen}
+		c.copies[v] = st
+		def, err := c.Object(v.Def)
+		if err != nil {
+			return nil, err
+		}
+
+		st.Def = def.(*StructType)
+		for i, val := range v.Val {
+			if st.Val[i], err = c.Object(val); err != nil {
+				return nil, err
+			}
+		}
+
+		return st, nil
+	case *StructType:
+		if cp, ok := c.copies[v]; ok {
+			return cp.(*StructType), nil
+		}
+
+		def := &StructType{Name: v.Name, Fields: v.Fields, Methods: make(map[string]FuncObject, len(v.Methods))}
+		c.copies[v] = def
+		for name, method := range v.Methods {
+			fn, err := c.Object(method)
+			if err != nil {
+				return nil, err
+			}
+
+			def.Methods[name] = fn.(FuncObject)
+		}
+
+		return def, nil
+	case *EnumType:
+		if cp, ok := c.copies[v]; ok {
+			return cp.(*EnumType), nil
+		}
+
+		en := &EnumType{Name: v.Name, Variants: make([]*VariantType, 0, len(v.Variants))}
+		c.copies[v] = en
+		for _, variant := range v.Variants {
+			cp := &VariantType{Enum: en, Name: variant.Name, Fields: variant.Fields, origin: variant.Origin()}
+			c.copies[variant] = cp
+			en.Variants = append(en.Variants, cp)
+		}
+
+		return en, nil
+	case *VariantType:
+		if _, err := c.Object(v.Enum); err != nil {
+			return nil, err
+		}
+
+		return c.copies[v].(*VariantType), nil
+	case *EnumObject:
+		if cp, ok := c.copies[v]; ok {
+			return cp.(*EnumObject), nil
+		}
+
+		en := &EnumObject{Val: make([]Object, len(v.Val))}
+		c.copies[v] = en
+		variant, err := c.Object(v.Variant)
+		if err != nil {
+			return nil, err
+		}
+
+		en.Variant = variant.(*VariantType)
+		for i, val := range v.Val {
+			if en.Val[i], err = c.Object(val); err != nil {
+				return nil, err
+			}
+		}
+
+		return en, nil
+	case FuncObject:
+		env, err := c.Env(v.Env)
+		if err != nil {
+			return nil, err
+		}
+
+		v.Env = env
+		return v, nil
+	case ComposedObject:
+		composed := ComposedObject{Funcs: make([]Object, len(v.Funcs))}
+		for i, fn := range v.Funcs {
+			var err error
+			if composed.Funcs[i], err = c.Object(fn); err != nil {
+				return nil, err
+			}
+		}
+
+		return composed, nil
+	case *IteratorObject:
+		return NewIterator(v.Name, func() (Object, bool, error) {
+			return nil, false, fmt.Errorf("can not use %s iterator of another task", v.Name)
+		}, nil), nil
+	}
+
+	return nil, fmt.Errorf("can not copy %s", obj.Type())
+}
+
+// Env copies the scope with its parents
+func (c *Copier) Env(env *Environment) (*Environment, error) {
+	if env == nil {
+		return nil, nil
+	}
+
+	if cp, ok := c.copies[env]; ok {
+		return cp.(*Environment), nil
+	}
+
+	cp := &Environment{}
+	c.copies[env] = cp
+	var err error
+	if cp.rootEnv, err = c.Env(env.rootEnv); err != nil {
+		return nil, err
+	}
+
+	if env.slots != nil {
+		cp.slots = make([]binding, len(env.slots))
+		for i, b := range env.slots {
+			if cp.slots[i].val, err = c.Object(b.val); err != nil {
+				return nil, err
+			}
+
+			cp.slots[i].constant = b.constant
+		}
+	}
+
+	if env.env != nil {
+		cp.env = make(map[string]binding, len(env.env))
+		for key, b := range env.env {
+			val, err := c.Object(b.val)
+			if err != nil {
+				return nil, err
+			}
+
+			cp.env[key] = binding{val: val, constant: b.constant}
+		}
+	}
+
+	return cp, nil
+}
@@ -1,47 +1,129 @@
 package object
 
+import "fmt"
+
+type binding struct {
+	val      Object
+	constant bool
+}
+
+// Environment scope of the variables. Variables resolved by the resolver are addressed by slots, the rest,
+// e.g. globals of the REPL, are looked up by name.
 type Environment struct {
 	rootEnv *Environment
-	env     map[string]Object
+	slots   []binding
+	env     map[string]binding
 }
 
 func NewEnv() *Environment {
 	return &Environment{
-		env: make(map[string]Object),
+		env: make(map[string]binding),
 	}
 }
 
+// DeriveEnv creates a nested scope, the map of names is allocated on the first definition by name
 func DeriveEnv(root *Environment) *Environment {
 	return &Environment{
 		rootEnv: root,
-		env:     make(map[string]Object),
 	}
 }
 
-func (e Environment) Set(key string, val Object) {
-	if _, ok := e.env[key]; ok {
-		e.env[key] = val
-		return
+// Set assigns the value to the closest scope defining the key. Assignment to the key that is not defined
+// in any of the scopes and to constants is an error.
+func (e *Environment) Set(key string, val Object) error {
+	for env := e; env != nil; env = env.rootEnv {
+		if b, ok := env.env[key]; ok {
+			if b.constant {
+				return fmt.Errorf("can not assign to constant %s", key)
+			}
+
+			env.env[key] = binding{val: val}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("identifier %s is not defined", key)
+}
+
+// Define sets the value in the current scope, shadowing values of the parent scopes
+func (e *Environment) Define(key string, val Object) {
+	if e.env == nil {
+		e.env = make(map[string]binding)
+	}
+
+	e.env[key] = binding{val: val}
+}
+
+// MakeConst marks the key defined in the current scope as a constant
+func (e *Environment) MakeConst(key string) {
+	if b, ok := e.env[key]; ok {
+		b.constant = true
+		e.env[key] = b
 	}
+}
+
+// Declared reports whether the key is defined in the current scope, parent scopes are not checked
+func (e *Environment) Declared(key string) bool {
+	_, ok := e.env[key]
+	return ok
+}
 
-	var parentEnv = e.rootEnv
-	for parentEnv != nil {
-		if _, ok := parentEnv.env[key]; ok {
-			parentEnv.env[key] = val
-			return
+func (e *Environment) Get(key string) (Object, bool) {
+	for env := e; env != nil; env = env.rootEnv {
+		if b, ok := env.env[key]; ok {
+			return b.val, true
 		}
+	}
+
+	return nil, false
+}
+
+func (e *Environment) ancestor(depth int) *Environment {
+	env := e
+	for ; depth > 0 && env != nil; depth-- {
+		env = env.rootEnv
+	}
+
+	return env
+}
+
+// DefineSlot sets the value of the slot in the current scope
+func (e *Environment) DefineSlot(slot int, val Object) {
+	if slot >= len(e.slots) {
+		e.slots = append(e.slots, make([]binding, slot+1-len(e.slots))...)
+	}
+
+	e.slots[slot] = binding{val: val}
+}
+
+// MakeConstSlot marks the slot of the current scope as a constant
+func (e *Environment) MakeConstSlot(slot int) {
+	if slot < len(e.slots) {
+		e.slots[slot].constant = true
+	}
+}
 
-		parentEnv = parentEnv.rootEnv
+// GetSlot returns the value of the slot of the scope depth levels above, false if the slot is not defined yet
+func (e *Environment) GetSlot(depth, slot int) (Object, bool) {
+	env := e.ancestor(depth)
+	if env == nil || slot >= len(env.slots) || env.slots[slot].val == nil {
+		return nil, false
 	}
 
-	e.env[key] = val
+	return env.slots[slot].val, true
 }
 
-func (e Environment) Get(key string) (Object, bool) {
-	val, ok := e.env[key]
-	if !ok && e.rootEnv != nil {
-		return e.rootEnv.Get(key)
+// SetSlot assigns the value to the slot of the scope depth levels above, key is used in the errors only
+func (e *Environment) SetSlot(key string, depth, slot int, val Object) error {
+	env := e.ancestor(depth)
+	if env == nil || slot >= len(env.slots) || env.slots[slot].val == nil {
+		return fmt.Errorf("identifier %s is not defined", key)
+	}
+
+	if env.slots[slot].constant {
+		return fmt.Errorf("can not assign to constant %s", key)
 	}
 
-	return val, ok
+	env.slots[slot].val = val
+	return nil
 }
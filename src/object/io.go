@@ -0,0 +1,324 @@
+package object
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ERROR_OBJ ObjectType = "ERROR"
+
+// ErrorObject failure returned by build in functions instead of their result, e.g. the missing file. Programs
+// check it with is_error and read err.message.
+type ErrorObject struct {
+	Message string
+}
+
+func (e ErrorObject) Type() ObjectType {
+	return ERROR_OBJ
+}
+
+func (e ErrorObject) Inspect() string {
+	return fmt.Sprintf("error(%s)", e.Message)
+}
+
+// ExitError stops the evaluation when the program calls exit, the host decides what to do with the code
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit with code %d", e.Code)
+}
+
+// Input buffered input of the program, it is shared by the tasks
+type Input struct {
+	mu sync.Mutex
+	r  *bufio.Reader
+}
+
+func NewInput(r io.Reader) *Input {
+	return &Input{
+		r: bufio.NewReader(r),
+	}
+}
+
+// DefaultInput the standard input of the process, evaluations share it, so lines buffered by one of them are
+// not lost for the others
+var DefaultInput = NewInput(os.Stdin)
+
+// fileError describes the failure of the operation with the file by the path given by the program
+func fileError(action, path string, err error) Object {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+
+	return ErrorObject{Message: fmt.Sprintf("can not %s %s: %s", action, path, err)}
+}
+
+func expectStrings(args []Object, n int) ([]string, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("expected %d arguments, got %d", n, len(args))
+	}
+
+	res := make([]string, 0, n)
+	for _, arg := range args {
+		s, ok := arg.(StringObject)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %s", arg.Type())
+		}
+
+		res = append(res, s.Val)
+	}
+
+	return res, nil
+}
+
+func readFile(rt *Runtime, args ...Object) (Object, error) {
+	strs, err := expectStrings(args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := rt.Capabilities.Path(FileRead, strs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// the size is checked before the file is read, so the limits hold for large files too
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileError("read", strs[0], err), nil
+	}
+
+	if info.Size() > math.MaxInt32 {
+		return ErrorObject{Message: fmt.Sprintf("can not read %s: file is too large", strs[0])}, nil
+	}
+
+	if err := rt.Budget.String(int(info.Size())); err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fileError("read", strs[0], err), nil
+	}
+
+	return StringObject{Val: string(content)}, nil
+}
+
+// writeFile writes the content to the file, flag tells whether the file is truncated or appended
+func writeFile(flag int) BuildInFunc {
+	return func(rt *Runtime, args ...Object) (Object, error) {
+		strs, err := expectStrings(args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		path, err := rt.Capabilities.Path(FileWrite, strs[0])
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|flag, 0644)
+		if err != nil {
+			return fileError("write", strs[0], err), nil
+		}
+
+		_, err = f.WriteString(strs[1])
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			return fileError("write", strs[0], err), nil
+		}
+
+		return NIL, nil
+	}
+}
+
+// listDir returns names of the entries of the directory ordered by name
+func listDir(rt *Runtime, args ...Object) (Object, error) {
+	strs, err := expectStrings(args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := rt.Capabilities.Path(FileRead, strs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fileError("list", strs[0], err), nil
+	}
+
+	if err := rt.Budget.Array(len(entries), len(entries)); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	sort.Strings(names)
+	res := make([]Object, 0, len(names))
+	for _, name := range names {
+		res = append(res, StringObject{Val: name})
+	}
+
+	return &ArrayObject{Val: res}, nil
+}
+
+func exists(rt *Runtime, args ...Object) (Object, error) {
+	strs, err := expectStrings(args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := rt.Capabilities.Path(FileRead, strs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return FALSE, nil
+		}
+
+		return fileError("check", strs[0], err), nil
+	}
+
+	return TRUE, nil
+}
+
+// readLine reads the next line of the standard input without the line break, nil at the end of the input
+func readLine(rt *Runtime, args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 arguments, got %d", len(args))
+	}
+
+	rt.Stdin.mu.Lock()
+	defer rt.Stdin.mu.Unlock()
+	return nextLine(rt)
+}
+
+// readLines reads the remaining lines of the standard input
+func readLines(rt *Runtime, args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 arguments, got %d", len(args))
+	}
+
+	rt.Stdin.mu.Lock()
+	defer rt.Stdin.mu.Unlock()
+	arr := &ArrayObject{}
+	for {
+		line, err := nextLine(rt)
+		if err != nil {
+			return nil, err
+		}
+
+		switch line.Type() {
+		case NIL_OBJ:
+			return arr, nil
+		case ERROR_OBJ:
+			return line, nil
+		}
+
+		if err := rt.Budget.Array(len(arr.Val)+1, 1); err != nil {
+			return nil, err
+		}
+
+		arr.Val = append(arr.Val, line)
+	}
+}
+
+// nextLine reads the line of the locked input, nil at the end of the input and the error value when reading fails
+func nextLine(rt *Runtime) (Object, error) {
+	var line strings.Builder
+	for {
+		part, isPrefix, err := rt.Stdin.r.ReadLine()
+		if err == io.EOF && line.Len() == 0 {
+			return NIL, nil
+		}
+
+		if err != nil && err != io.EOF {
+			return ErrorObject{Message: fmt.Sprintf("can not read stdin: %s", err)}, nil
+		}
+
+		if err := rt.Budget.String(line.Len() + len(part)); err != nil {
+			return nil, err
+		}
+
+		line.Write(part)
+		if !isPrefix || err == io.EOF {
+			return StringObject{Val: line.String()}, nil
+		}
+	}
+}
+
+// env returns the value of the environment variable, nil if it is not set
+func env(_ *Runtime, args ...Object) (Object, error) {
+	strs, err := expectStrings(args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	val, ok := os.LookupEnv(strs[0])
+	if !ok {
+		return NIL, nil
+	}
+
+	return StringObject{Val: val}, nil
+}
+
+// scriptArgs returns the arguments of the program given by the host, e.g. the arguments after the file name
+func scriptArgs(rt *Runtime, args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 arguments, got %d", len(args))
+	}
+
+	res := make([]Object, 0, len(rt.Args))
+	for _, arg := range rt.Args {
+		res = append(res, StringObject{Val: arg})
+	}
+
+	return &ArrayObject{Val: res}, nil
+}
+
+// exit stops the program with the code, exit() is exit(0)
+func exit(_ *Runtime, args ...Object) (Object, error) {
+	if len(args) == 0 {
+		return nil, &ExitError{}
+	}
+
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+
+	code, ok := args[0].(IntegerObject)
+	if !ok || code.Val < 0 || code.Val > 255 {
+		return nil, fmt.Errorf("exit code must be an integer from 0 to 255, got %s", args[0].Inspect())
+	}
+
+	return nil, &ExitError{Code: int(code.Val)}
+}
+
+func isError(_ *Runtime, args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+
+	return BoolObject{Val: args[0].Type() == ERROR_OBJ}, nil
+}
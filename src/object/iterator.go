@@ -0,0 +1,310 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var ITERATOR_OBJ ObjectType = "ITERATOR"
+
+// IteratorObject lazy sequence of values. Iterators of arrays, strings, maps and ranges read the collection as they go,
+// generators run the function until the next yield. Close releases the iterator, e.g. stops the suspended
+// generator, consumers which stop before the end must close the iterator.
+type IteratorObject struct {
+	// Name describes the source of the values, e.g. array or generator
+	Name  string
+	next  func() (Object, bool, error)
+	close func()
+	once  sync.Once
+	done  bool
+}
+
+// NewIterator creates the iterator, next returns false when there are no more values, close may be nil
+func NewIterator(name string, next func() (Object, bool, error), close func()) *IteratorObject {
+	return &IteratorObject{
+		Name:  name,
+		next:  next,
+		close: close,
+	}
+}
+
+func (it *IteratorObject) Type() ObjectType {
+	return ITERATOR_OBJ
+}
+
+func (it *IteratorObject) Inspect() string {
+	return it.Name + " iterator"
+}
+
+// Next returns the next value, false if the iterator is exhausted. The iterator is closed when it is exhausted
+// or fails.
+func (it *IteratorObject) Next() (Object, bool, error) {
+	if it.done {
+		return nil, false, nil
+	}
+
+	val, ok, err := it.next()
+	if err != nil || !ok {
+		it.Close()
+		return nil, false, err
+	}
+
+	return val, true, nil
+}
+
+// Close stops the iterator, it is safe to call Close more than once
+func (it *IteratorObject) Close() {
+	it.done = true
+	it.once.Do(func() {
+		if it.close != nil {
+			it.close()
+		}
+	})
+}
+
+// Iterate returns the iterator over the values of the collection, maps produce [key, value] pairs ordered
+// by the key and channels the received values until they are closed. Iterators are returned as is.
+func Iterate(obj Object) (*IteratorObject, error) {
+	switch v := obj.(type) {
+	case *IteratorObject:
+		return v, nil
+	case *RangeObject:
+		return rangeIter(v), nil
+	case *ChannelObject:
+		return v.Iter(nil), nil
+	case *ArrayObject:
+		i := 0
+		return NewIterator("array", func() (Object, bool, error) {
+			if i >= len(v.Val) {
+				return nil, false, nil
+			}
+
+			i++
+			return v.Val[i-1], true, nil
+		}, nil), nil
+	case StringObject:
+		i := 0
+		return NewIterator("string", func() (Object, bool, error) {
+			if i >= len(v.Val) {
+				return nil, false, nil
+			}
+
+			i++
+			return StringObject{Val: v.Val[i-1 : i]}, true, nil
+		}, nil), nil
+	case *MapObject:
+		keys := sortedKeys(v)
+		i := 0
+		return NewIterator("map", func() (Object, bool, error) {
+			for i < len(keys) {
+				key := keys[i]
+				i++
+				// keys deleted during the iteration are skipped
+				if val, ok := v.Val[key]; ok {
+					return &ArrayObject{Val: []Object{key, val}}, true, nil
+				}
+			}
+
+			return nil, false, nil
+		}, nil), nil
+	}
+
+	return nil, fmt.Errorf("can not iterate over %s", obj.Type())
+}
+
+func iteratorNext(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return nil, err
+	}
+
+	val, ok, err := args[0].(*IteratorObject).Next()
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		val = NIL
+	}
+
+	return &MapObject{Val: map[Object]Object{
+		StringObject{Val: "value"}: val,
+		StringObject{Val: "done"}:  BoolObject{Val: !ok},
+	}}, nil
+}
+
+func iteratorMap(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	it := args[0].(*IteratorObject)
+	return NewIterator(it.Name, func() (Object, bool, error) {
+		val, ok, err := it.Next()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+
+		val, err = rt.Call(args[1], val)
+		return val, err == nil, err
+	}, it.Close), nil
+}
+
+func iteratorFilter(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	it := args[0].(*IteratorObject)
+	return NewIterator(it.Name, func() (Object, bool, error) {
+		for {
+			val, ok, err := it.Next()
+			if err != nil || !ok {
+				return nil, false, err
+			}
+
+			keep, err := rt.Call(args[1], val)
+			if err != nil {
+				return nil, false, err
+			}
+
+			cond, err := ToBool(keep)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if cond.Val {
+				return val, true, nil
+			}
+		}
+	}, it.Close), nil
+}
+
+// iteratorTake limits the iterator to the first n values, the source is closed after the last one
+func iteratorTake(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	n, ok := args[1].(IntegerObject)
+	if !ok {
+		return nil, fmt.Errorf("expected integer, got %s", args[1].Type())
+	}
+
+	it := args[0].(*IteratorObject)
+	taken := int64(0)
+	return NewIterator(it.Name, func() (Object, bool, error) {
+		if taken >= n.Val {
+			return nil, false, nil
+		}
+
+		taken++
+		return it.Next()
+	}, it.Close), nil
+}
+
+// each calls fn for the values of the iterator until it is exhausted, fn returns false or the evaluation
+// is canceled, the iterator is closed in all cases
+func each(rt *Runtime, it *IteratorObject, fn func(val Object) (bool, error)) error {
+	defer it.Close()
+	for {
+		if err := rt.Stopped(); err != nil {
+			return err
+		}
+
+		val, ok, err := it.Next()
+		if err != nil || !ok {
+			return err
+		}
+
+		more, err := fn(val)
+		if err != nil || !more {
+			return err
+		}
+	}
+}
+
+// Collect reads the remaining values of the iterator to the array, the budget and the cancellation of
+// the evaluation stop endless iterators
+func Collect(rt *Runtime, it *IteratorObject) (*ArrayObject, error) {
+	arr := &ArrayObject{}
+	err := each(rt, it, func(val Object) (bool, error) {
+		if err := rt.Budget.Array(len(arr.Val)+1, 1); err != nil {
+			return false, err
+		}
+
+		arr.Val = append(arr.Val, val)
+		return true, nil
+	})
+
+	return arr, err
+}
+
+func iteratorToArray(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return nil, err
+	}
+
+	return Collect(rt, args[0].(*IteratorObject))
+}
+
+func iteratorReduce(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 3); err != nil {
+		return nil, err
+	}
+
+	acc := args[2]
+	err := each(rt, args[0].(*IteratorObject), func(val Object) (bool, error) {
+		var err error
+		acc, err = rt.Call(args[1], acc, val)
+		return true, err
+	})
+
+	return acc, err
+}
+
+func iteratorContains(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	found := false
+	err := each(rt, args[0].(*IteratorObject), func(val Object) (bool, error) {
+		found = Equal(val, args[1])
+		return !found, nil
+	})
+
+	return BoolObject{Val: found}, err
+}
+
+func iteratorJoin(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	sep, ok := args[1].(StringObject)
+	if !ok {
+		return nil, fmt.Errorf("expected string separator, got %s", args[1].Type())
+	}
+
+	var b strings.Builder
+	err := each(rt, args[0].(*IteratorObject), func(val Object) (bool, error) {
+		part := val.Inspect()
+		if b.Len() != 0 {
+			part = sep.Val + part
+		}
+
+		if err := rt.Budget.String(b.Len() + len(part)); err != nil {
+			return false, err
+		}
+
+		b.WriteString(part)
+		return true, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return StringObject{Val: b.String()}, nil
+}
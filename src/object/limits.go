@@ -0,0 +1,147 @@
+package object
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Limit names the resource bounded by Limits
+type Limit string
+
+const (
+	StepsLimit     Limit = "steps"
+	CallDepthLimit Limit = "call depth"
+	StringLimit    Limit = "string length"
+	ArrayLimit     Limit = "array length"
+	MapLimit       Limit = "map size"
+	AllocLimit     Limit = "allocation"
+)
+
+// approximate sizes of the array element and the map entry in bytes, used to account allocations
+const (
+	elemSize  = 16
+	entrySize = 64
+)
+
+// Limits bounds the resources used by the evaluation, zero fields are not limited. MaxAlloc is the approximate
+// number of bytes allocated by strings, arrays and maps during the whole evaluation, memory released by the
+// garbage collector is not subtracted.
+type Limits struct {
+	MaxSteps     int64
+	MaxCallDepth int
+	MaxStringLen int
+	MaxArrayLen  int
+	MaxMapLen    int
+	MaxAlloc     int64
+}
+
+// LimitError the evaluation exceeded the limit
+type LimitError struct {
+	Limit Limit
+	Max   int64
+}
+
+func (le *LimitError) Error() string {
+	return fmt.Sprintf("%s limit of %d exceeded", le.Limit, le.Max)
+}
+
+// Budget counts the resources used by the evaluation against its limits, it is shared by the tasks of the
+// evaluation. Methods of the nil budget never fail.
+type Budget struct {
+	limits    Limits
+	steps     atomic.Int64
+	allocated atomic.Int64
+}
+
+func NewBudget(limits Limits) *Budget {
+	return &Budget{
+		limits: limits,
+	}
+}
+
+// Step counts the evaluation step
+func (b *Budget) Step() error {
+	if b == nil || b.limits.MaxSteps == 0 {
+		return nil
+	}
+
+	if b.steps.Add(1) > b.limits.MaxSteps {
+		return &LimitError{Limit: StepsLimit, Max: b.limits.MaxSteps}
+	}
+
+	return nil
+}
+
+// CallDepth checks the depth of the call
+func (b *Budget) CallDepth(depth int) error {
+	if b == nil || b.limits.MaxCallDepth == 0 || depth <= b.limits.MaxCallDepth {
+		return nil
+	}
+
+	return &LimitError{Limit: CallDepthLimit, Max: int64(b.limits.MaxCallDepth)}
+}
+
+// String checks the length of the string about to be created and accounts its allocation
+func (b *Budget) String(n int) error {
+	if b == nil {
+		return nil
+	}
+
+	if b.limits.MaxStringLen != 0 && n > b.limits.MaxStringLen {
+		return &LimitError{Limit: StringLimit, Max: int64(b.limits.MaxStringLen)}
+	}
+
+	return b.alloc(int64(n))
+}
+
+// Array checks the length of the array with added elements, only the added elements are accounted
+func (b *Budget) Array(n, added int) error {
+	if b == nil {
+		return nil
+	}
+
+	if b.limits.MaxArrayLen != 0 && n > b.limits.MaxArrayLen {
+		return &LimitError{Limit: ArrayLimit, Max: int64(b.limits.MaxArrayLen)}
+	}
+
+	return b.alloc(int64(added) * elemSize)
+}
+
+// Map checks the size of the map with added entries, only the added entries are accounted
+func (b *Budget) Map(n, added int) error {
+	if b == nil {
+		return nil
+	}
+
+	if b.limits.MaxMapLen != 0 && n > b.limits.MaxMapLen {
+		return &LimitError{Limit: MapLimit, Max: int64(b.limits.MaxMapLen)}
+	}
+
+	return b.alloc(int64(added) * entrySize)
+}
+
+// BigInt accounts the big integer with the estimated number of bits before it is computed, integers fitting into
+// int64 are not accounted
+func (b *Budget) BigInt(bits uint64) error {
+	if b == nil || bits <= 64 {
+		return nil
+	}
+
+	if b.limits.MaxAlloc != 0 && bits/8 > uint64(b.limits.MaxAlloc) {
+		return &LimitError{Limit: AllocLimit, Max: b.limits.MaxAlloc}
+	}
+
+	return b.alloc(int64(bits / 8))
+}
+
+func (b *Budget) alloc(bytes int64) error {
+	if b.limits.MaxAlloc == 0 {
+		return nil
+	}
+
+	if b.allocated.Add(bytes) > b.limits.MaxAlloc {
+		return &LimitError{Limit: AllocLimit, Max: b.limits.MaxAlloc}
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Math build in namespace, functions are accessed by name: math["abs"](-1)
+var Math = &MapObject{
+	Frozen: true,
+	Val: map[Object]Object{
+		StringObject{Val: "abs"}:   BuildInFunc(mathAbs),
+		StringObject{Val: "min"}:   BuildInFunc(mathMin),
+		StringObject{Val: "max"}:   BuildInFunc(mathMax),
+		StringObject{Val: "pow"}:   BuildInFunc(mathPow),
+		StringObject{Val: "sqrt"}:  BuildInFunc(mathSqrt),
+		StringObject{Val: "floor"}: BuildInFunc(mathFloor),
+		StringObject{Val: "ceil"}:  BuildInFunc(mathCeil),
+		StringObject{Val: "gcd"}:   BuildInFunc(mathGcd),
+		StringObject{Val: "clamp"}: BuildInFunc(mathClamp),
+	},
+}
+
+func expectNumbers(args []Object, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("expected %d argument, got %d", n, len(args))
+	}
+
+	for _, arg := range args {
+		if !IsNumber(arg) {
+			return fmt.Errorf("expected number, got %s", arg.Type())
+		}
+	}
+
+	return nil
+}
+
+func mathAbs(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectNumbers(args, 1); err != nil {
+		return nil, err
+	}
+
+	switch v := args[0].(type) {
+	case IntegerObject:
+		if v.Val < 0 {
+			return NegInt(v.Val), nil
+		}
+
+		return v, nil
+	case BigIntObject:
+		return NewBigInt(new(big.Int).Abs(v.Val)), nil
+	default:
+		return FloatObject{Val: math.Abs(v.(FloatObject).Val)}, nil
+	}
+}
+
+// mathMin accepts either numbers or a single array of numbers
+func mathMin(_ *Runtime, args ...Object) (Object, error) {
+	return extremum(-1, args)
+}
+
+func mathMax(_ *Runtime, args ...Object) (Object, error) {
+	return extremum(1, args)
+}
+
+func extremum(sign int, args []Object) (Object, error) {
+	if len(args) == 1 {
+		if arr, ok := args[0].(*ArrayObject); ok {
+			args = arr.Val
+		}
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("expected at least 1 number")
+	}
+
+	res := args[0]
+	for _, arg := range args {
+		cmp, err := CompareNumbers(arg, res)
+		if err != nil {
+			return nil, err
+		}
+
+		if cmp == sign {
+			res = arg
+		}
+	}
+
+	return res, nil
+}
+
+func mathPow(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectNumbers(args, 2); err != nil {
+		return nil, err
+	}
+
+	return Pow(rt.Budget, args[0], args[1])
+}
+
+func mathSqrt(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectNumbers(args, 1); err != nil {
+		return nil, err
+	}
+
+	f, _ := ToFloat(args[0])
+	if f < 0 {
+		return nil, fmt.Errorf("square root of negative number")
+	}
+
+	return FloatObject{Val: math.Sqrt(f)}, nil
+}
+
+func mathFloor(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectNumbers(args, 1); err != nil {
+		return nil, err
+	}
+
+	if f, ok := args[0].(FloatObject); ok {
+		return FloatToInt(math.Floor(f.Val))
+	}
+
+	return args[0], nil
+}
+
+func mathCeil(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectNumbers(args, 1); err != nil {
+		return nil, err
+	}
+
+	if f, ok := args[0].(FloatObject); ok {
+		return FloatToInt(math.Ceil(f.Val))
+	}
+
+	return args[0], nil
+}
+
+func mathGcd(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectNumbers(args, 2); err != nil {
+		return nil, err
+	}
+
+	a, ok := ToBig(args[0])
+	b, ok2 := ToBig(args[1])
+	if !ok || !ok2 {
+		return nil, fmt.Errorf("expected integers")
+	}
+
+	return NewBigInt(new(big.Int).GCD(nil, nil, a, b)), nil
+}
+
+func mathClamp(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectNumbers(args, 3); err != nil {
+		return nil, err
+	}
+
+	val, low, high := args[0], args[1], args[2]
+	if cmp, _ := CompareNumbers(low, high); cmp > 0 {
+		return nil, fmt.Errorf("lower bound %s is greater than upper bound %s", low.Inspect(), high.Inspect())
+	}
+
+	if cmp, _ := CompareNumbers(val, low); cmp < 0 {
+		return low, nil
+	}
+
+	if cmp, _ := CompareNumbers(val, high); cmp > 0 {
+		return high, nil
+	}
+
+	return val, nil
+}
@@ -0,0 +1,415 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Caller calls the function value with the arguments, build in methods taking callbacks, e.g. map and filter,
+// get it from the evaluator
+type Caller func(fn Object, args ...Object) (Object, error)
+
+// Runtime the evaluation calling the build in function or method, Budget bounds the values they create,
+// Capabilities the resources of the host they can use, Stdin and Args are the input and the arguments of
+// the program. Done is closed when the evaluation is canceled.
+type Runtime struct {
+	Call         Caller
+	Budget       *Budget
+	Capabilities Capabilities
+	Stdin        *Input
+	Args         []string
+	Done         <-chan struct{}
+}
+
+// Stopped returns ErrStopped when the evaluation is canceled, build in functions looping over the values check it
+// for every value
+func (rt *Runtime) Stopped() error {
+	select {
+	case <-rt.Done:
+		return ErrStopped
+	default:
+		return nil
+	}
+}
+
+// BuildInMethod method of the build in type, the receiver is the first argument: [1].push(2) is push([1], 2)
+type BuildInMethod func(rt *Runtime, args ...Object) (Object, error)
+
+// Methods build in methods by the type of the receiver
+var Methods = map[ObjectType]map[string]BuildInMethod{
+	ARRAY_OBJ: {
+		"len":      BuildInMethod(BuildIns["len"]),
+		"push":     arrayPush,
+		"pop":      arrayPop,
+		"map":      arrayMap,
+		"filter":   arrayFilter,
+		"reduce":   arrayReduce,
+		"join":     arrayJoin,
+		"contains": arrayContains,
+		"reverse":  arrayReverse,
+	},
+	STRING_OBJ: {
+		"len":         BuildInMethod(BuildIns["len"]),
+		"upper":       stringFunc(strings.ToUpper),
+		"lower":       stringFunc(strings.ToLower),
+		"trim":        stringFunc(strings.TrimSpace),
+		"split":       stringSplit,
+		"repeat":      stringRepeat,
+		"contains":    stringPredicate(strings.Contains),
+		"starts_with": stringPredicate(strings.HasPrefix),
+		"ends_with":   stringPredicate(strings.HasSuffix),
+	},
+	MAP_OBJ: {
+		"len":    mapLen,
+		"keys":   mapKeys,
+		"values": mapValues,
+		"has":    mapHas,
+		"delete": mapDelete,
+	},
+	ITERATOR_OBJ: {
+		"next":     iteratorNext,
+		"map":      iteratorMap,
+		"filter":   iteratorFilter,
+		"take":     iteratorTake,
+		"reduce":   iteratorReduce,
+		"contains": iteratorContains,
+		"join":     iteratorJoin,
+		"to_array": iteratorToArray,
+	},
+	RANGE_OBJ: {
+		"len":      rangeLen,
+		"step":     rangeStep,
+		"contains": rangeContains,
+		"iter":     BuildInMethod(BuildIns["iter"]),
+		"map":      rangeIterator(iteratorMap),
+		"filter":   rangeIterator(iteratorFilter),
+		"take":     rangeIterator(iteratorTake),
+		"reduce":   rangeIterator(iteratorReduce),
+		"join":     rangeIterator(iteratorJoin),
+		"to_array": rangeIterator(iteratorToArray),
+	},
+}
+
+func expectArgs(args []Object, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("expected %d arguments, got %d", n-1, len(args)-1)
+	}
+
+	return nil
+}
+
+func arrayPush(rt *Runtime, args ...Object) (Object, error) {
+	arr := args[0].(*ArrayObject)
+	if arr.Frozen {
+		return nil, fmt.Errorf("can not modify frozen array")
+	}
+
+	if err := rt.Budget.Array(len(arr.Val)+len(args)-1, len(args)-1); err != nil {
+		return nil, err
+	}
+
+	arr.Val = append(arr.Val, args[1:]...)
+	return arr, nil
+}
+
+func arrayPop(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return nil, err
+	}
+
+	arr := args[0].(*ArrayObject)
+	if arr.Frozen {
+		return nil, fmt.Errorf("can not modify frozen array")
+	}
+
+	if len(arr.Val) == 0 {
+		return nil, fmt.Errorf("can not pop from empty array")
+	}
+
+	last := arr.Val[len(arr.Val)-1]
+	arr.Val = arr.Val[:len(arr.Val)-1]
+	return last, nil
+}
+
+func arrayMap(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	arr := args[0].(*ArrayObject)
+	if err := rt.Budget.Array(len(arr.Val), len(arr.Val)); err != nil {
+		return nil, err
+	}
+
+	res := make([]Object, 0, len(arr.Val))
+	for _, elem := range arr.Val {
+		val, err := rt.Call(args[1], elem)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, val)
+	}
+
+	return &ArrayObject{Val: res}, nil
+}
+
+func arrayFilter(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	arr := args[0].(*ArrayObject)
+	var res []Object
+	for _, elem := range arr.Val {
+		val, err := rt.Call(args[1], elem)
+		if err != nil {
+			return nil, err
+		}
+
+		keep, err := ToBool(val)
+		if err != nil {
+			return nil, err
+		}
+
+		if keep.Val {
+			res = append(res, elem)
+		}
+	}
+
+	if err := rt.Budget.Array(len(res), len(res)); err != nil {
+		return nil, err
+	}
+
+	return &ArrayObject{Val: append(make([]Object, 0, len(res)), res...)}, nil
+}
+
+// arrayReduce folds the array with fn(acc, elem), the initial value is the second argument
+func arrayReduce(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 3); err != nil {
+		return nil, err
+	}
+
+	acc := args[2]
+	for _, elem := range args[0].(*ArrayObject).Val {
+		var err error
+		acc, err = rt.Call(args[1], acc, elem)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return acc, nil
+}
+
+func arrayJoin(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	it, err := Iterate(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return iteratorJoin(rt, it, args[1])
+}
+
+func arrayContains(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	for _, elem := range args[0].(*ArrayObject).Val {
+		if Equal(elem, args[1]) {
+			return TRUE, nil
+		}
+	}
+
+	return FALSE, nil
+}
+
+func arrayReverse(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return nil, err
+	}
+
+	arr := args[0].(*ArrayObject).Val
+	if err := rt.Budget.Array(len(arr), len(arr)); err != nil {
+		return nil, err
+	}
+
+	res := make([]Object, 0, len(arr))
+	for i := len(arr) - 1; i >= 0; i-- {
+		res = append(res, arr[i])
+	}
+
+	return &ArrayObject{Val: res}, nil
+}
+
+func stringFunc(fn func(string) string) BuildInMethod {
+	return func(rt *Runtime, args ...Object) (Object, error) {
+		if err := expectArgs(args, 1); err != nil {
+			return nil, err
+		}
+
+		res := fn(args[0].(StringObject).Val)
+		if err := rt.Budget.String(len(res)); err != nil {
+			return nil, err
+		}
+
+		return StringObject{Val: res}, nil
+	}
+}
+
+func stringPredicate(fn func(s, sub string) bool) BuildInMethod {
+	return func(_ *Runtime, args ...Object) (Object, error) {
+		if err := expectArgs(args, 2); err != nil {
+			return nil, err
+		}
+
+		sub, ok := args[1].(StringObject)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %s", args[1].Type())
+		}
+
+		if fn(args[0].(StringObject).Val, sub.Val) {
+			return TRUE, nil
+		}
+
+		return FALSE, nil
+	}
+}
+
+func stringSplit(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	sep, ok := args[1].(StringObject)
+	if !ok {
+		return nil, fmt.Errorf("expected string separator, got %s", args[1].Type())
+	}
+
+	parts := strings.Split(args[0].(StringObject).Val, sep.Val)
+	if err := rt.Budget.Array(len(parts), len(parts)); err != nil {
+		return nil, err
+	}
+
+	res := make([]Object, 0, len(parts))
+	for _, part := range parts {
+		res = append(res, StringObject{Val: part})
+	}
+
+	return &ArrayObject{Val: res}, nil
+}
+
+// stringRepeat returns the string repeated n times, the length is checked before the string is created
+func stringRepeat(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	n, ok := args[1].(IntegerObject)
+	if !ok || n.Val < 0 {
+		return nil, fmt.Errorf("expected non negative integer, got %s", args[1].Inspect())
+	}
+
+	s := args[0].(StringObject).Val
+	if len(s) != 0 && n.Val > math.MaxInt32/int64(len(s)) {
+		return nil, fmt.Errorf("repeated string is too long")
+	}
+
+	if err := rt.Budget.String(len(s) * int(n.Val)); err != nil {
+		return nil, err
+	}
+
+	return StringObject{Val: strings.Repeat(s, int(n.Val))}, nil
+}
+
+func mapLen(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return nil, err
+	}
+
+	return IntegerObject{Val: int64(len(args[0].(*MapObject).Val))}, nil
+}
+
+// sortedKeys returns keys of the map ordered by their representation, so the order does not change between runs
+func sortedKeys(mp *MapObject) []Object {
+	keys := make([]Object, 0, len(mp.Val))
+	for k := range mp.Val {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Inspect() < keys[j].Inspect()
+	})
+
+	return keys
+}
+
+func mapKeys(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return nil, err
+	}
+
+	mp := args[0].(*MapObject)
+	if err := rt.Budget.Array(len(mp.Val), len(mp.Val)); err != nil {
+		return nil, err
+	}
+
+	return &ArrayObject{Val: sortedKeys(mp)}, nil
+}
+
+func mapValues(rt *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return nil, err
+	}
+
+	mp := args[0].(*MapObject)
+	if err := rt.Budget.Array(len(mp.Val), len(mp.Val)); err != nil {
+		return nil, err
+	}
+
+	keys := sortedKeys(mp)
+	vals := make([]Object, 0, len(keys))
+	for _, k := range keys {
+		vals = append(vals, mp.Val[k])
+	}
+
+	return &ArrayObject{Val: vals}, nil
+}
+
+func mapHas(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	if _, ok := args[0].(*MapObject).Get(args[1]); ok {
+		return TRUE, nil
+	}
+
+	return FALSE, nil
+}
+
+// mapDelete removes the key and returns its value, nil if the key is missing
+func mapDelete(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	mp := args[0].(*MapObject)
+	if mp.Frozen {
+		return nil, fmt.Errorf("can not modify frozen map")
+	}
+
+	val, ok := mp.Delete(args[1])
+	if !ok {
+		return NIL, nil
+	}
+
+	return val, nil
+}
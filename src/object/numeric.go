@@ -0,0 +1,231 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+// NewBigInt narrows v back to IntegerObject when it fits into int64, so BigIntObject exists only while
+// the value is out of int64 range.
+func NewBigInt(v *big.Int) Object {
+	if v.IsInt64() {
+		return IntegerObject{
+			Val: v.Int64(),
+		}
+	}
+
+	return BigIntObject{
+		Val: v,
+	}
+}
+
+func IsNumber(obj Object) bool {
+	switch obj.(type) {
+	case IntegerObject, BigIntObject, FloatObject:
+		return true
+	}
+
+	return false
+}
+
+// ToBig converts integer objects to *big.Int, returns false for any other object
+func ToBig(obj Object) (*big.Int, bool) {
+	switch v := obj.(type) {
+	case IntegerObject:
+		return big.NewInt(v.Val), true
+	case BigIntObject:
+		return v.Val, true
+	}
+
+	return nil, false
+}
+
+// ToFloat converts number objects to float64, returns false for any other object
+func ToFloat(obj Object) (float64, bool) {
+	switch v := obj.(type) {
+	case IntegerObject:
+		return float64(v.Val), true
+	case BigIntObject:
+		f, _ := new(big.Float).SetInt(v.Val).Float64()
+		return f, true
+	case FloatObject:
+		return v.Val, true
+	}
+
+	return 0, false
+}
+
+// FloatToInt truncated float to the integer object, the value is promoted to BigIntObject if it does not fit into int64
+func FloatToInt(f float64) (Object, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("can not convert %v to integer", f)
+	}
+
+	if f >= math.MinInt64 && f < math.MaxInt64 {
+		return IntegerObject{Val: int64(f)}, nil
+	}
+
+	v, _ := big.NewFloat(f).Int(nil)
+	return NewBigInt(v), nil
+}
+
+// CompareNumbers returns -1, 0 or 1 as a is less, equal or greater than b
+func CompareNumbers(a, b Object) (int, error) {
+	if !IsNumber(a) || !IsNumber(b) {
+		return 0, fmt.Errorf("can not compare %s and %s", a.Type(), b.Type())
+	}
+
+	if a.Type() == FLOAT_OBJ || b.Type() == FLOAT_OBJ {
+		af, _ := ToFloat(a)
+		bf, _ := ToFloat(b)
+		switch {
+		case af < bf:
+			return -1, nil
+		case af > bf:
+			return 1, nil
+		}
+
+		return 0, nil
+	}
+
+	ab, _ := ToBig(a)
+	bb, _ := ToBig(b)
+	return ab.Cmp(bb), nil
+}
+
+func AddInt(a, b int64) Object {
+	c := a + b
+	if (b > 0 && c < a) || (b < 0 && c > a) {
+		return NewBigInt(new(big.Int).Add(big.NewInt(a), big.NewInt(b)))
+	}
+
+	return IntegerObject{Val: c}
+}
+
+func SubInt(a, b int64) Object {
+	c := a - b
+	if (b > 0 && c > a) || (b < 0 && c < a) {
+		return NewBigInt(new(big.Int).Sub(big.NewInt(a), big.NewInt(b)))
+	}
+
+	return IntegerObject{Val: c}
+}
+
+func MulInt(a, b int64) Object {
+	if a == 0 || b == 0 {
+		return IntegerObject{Val: 0}
+	}
+
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return NewBigInt(new(big.Int).Mul(big.NewInt(a), big.NewInt(b)))
+	}
+
+	return IntegerObject{Val: c}
+}
+
+// DivInt truncated division, b must not be zero
+func DivInt(a, b int64) Object {
+	if a == math.MinInt64 && b == -1 {
+		return NewBigInt(new(big.Int).Neg(big.NewInt(a)))
+	}
+
+	return IntegerObject{Val: a / b}
+}
+
+func NegInt(a int64) Object {
+	if a == math.MinInt64 {
+		return NewBigInt(new(big.Int).Neg(big.NewInt(a)))
+	}
+
+	return IntegerObject{Val: -a}
+}
+
+// LshInt left shift, the result is promoted to BigIntObject instead of dropping the high bits
+func LshInt(a int64, n uint) Object {
+	if n < 63 {
+		// the shift fits when the n+1 highest bits are all equal to the sign bit
+		if high := a >> (63 - n); high == 0 || high == -1 {
+			return IntegerObject{Val: a << n}
+		}
+	}
+
+	return NewBigInt(new(big.Int).Lsh(big.NewInt(a), n))
+}
+
+// IntBits returns the number of bits of the absolute value of a, the same as BitLen of the big integer
+func IntBits(a int64) int {
+	if a < 0 {
+		// -a overflows for math.MinInt64, but its unsigned value is still the absolute one
+		return bits.Len64(uint64(-a))
+	}
+
+	return bits.Len64(uint64(a))
+}
+
+// AddBits estimates the number of bits of the sum or the difference of integers with a and b bits
+func AddBits(a, b int) uint64 {
+	return uint64(max(a, b)) + 1
+}
+
+// MulBits estimates the number of bits of the product of integers with a and b bits
+func MulBits(a, b int) uint64 {
+	return uint64(a) + uint64(b)
+}
+
+// PowBits estimates the number of bits of base ** exp for integer operands, math.MaxUint64 when the estimate
+// does not fit into uint64
+func PowBits(base, exp *big.Int) uint64 {
+	if exp.Sign() <= 0 || base.CmpAbs(big.NewInt(1)) <= 0 {
+		return 1
+	}
+
+	if !exp.IsUint64() {
+		return math.MaxUint64
+	}
+
+	hi, lo := bits.Mul64(exp.Uint64(), uint64(base.BitLen()))
+	if hi != 0 {
+		return math.MaxUint64
+	}
+
+	return lo
+}
+
+// LshBits estimates the number of bits of a << n
+func LshBits(a *big.Int, n uint64) uint64 {
+	if a.Sign() == 0 {
+		return 1
+	}
+
+	if n > math.MaxUint64-uint64(a.BitLen()) {
+		return math.MaxUint64
+	}
+
+	return uint64(a.BitLen()) + n
+}
+
+// Pow raises base to the power of exp. Integers stay integers, promoted to BigIntObject on overflow,
+// negative integer exponent and float operands produce FloatObject. The size of the integer result is
+// checked against the budget before it is computed.
+func Pow(budget *Budget, base, exp Object) (Object, error) {
+	if !IsNumber(base) || !IsNumber(exp) {
+		return nil, fmt.Errorf("can not raise %s to the power of %s", base.Type(), exp.Type())
+	}
+
+	b, baseIsInt := ToBig(base)
+	e, expIsInt := ToBig(exp)
+	if baseIsInt && expIsInt && e.Sign() >= 0 {
+		if err := budget.BigInt(PowBits(b, e)); err != nil {
+			return nil, err
+		}
+
+		return NewBigInt(new(big.Int).Exp(b, e, nil)), nil
+	}
+
+	bf, _ := ToFloat(base)
+	ef, _ := ToFloat(exp)
+	return FloatObject{Val: math.Pow(bf, ef)}, nil
+}
@@ -5,20 +5,38 @@ import (
 	"fmt"
 	"github.com/charkpep/yami/src/parser"
 	"io"
+	"math"
+	"math/big"
+	"math/rand"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
 	FUNC_OBJ    ObjectType = "FUNC"
 	RETURN_OBJ  ObjectType = "RETURN"
 	INTEGER_OBJ ObjectType = "INTEGER"
+	BIGINT_OBJ  ObjectType = "BIGINT"
+	FLOAT_OBJ   ObjectType = "FLOAT"
 	BOOL_OBJ    ObjectType = "BOOL"
 	STRING_OBJ  ObjectType = "STRING"
 	NIL_OBJ     ObjectType = "NIL"
 	ARRAY_OBJ   ObjectType = "ARRAY"
 	MAP_OBJ     ObjectType = "MAP"
 	BUILDIN_OBJ ObjectType = "BUILDIN"
+	STRUCT_OBJ  ObjectType = "STRUCT"
+	// STRUCT_TYPE_OBJ declared struct, calling it creates the struct
+	STRUCT_TYPE_OBJ ObjectType = "STRUCT_TYPE"
+	ENUM_OBJ        ObjectType = "ENUM"
+	// ENUM_TYPE_OBJ declared enum, its variants are accessed as members, e.g. Result.Ok
+	ENUM_TYPE_OBJ ObjectType = "ENUM_TYPE"
+	// VARIANT_OBJ variant with fields, calling it creates the enum value
+	VARIANT_OBJ ObjectType = "VARIANT"
+	// COMPOSED_OBJ functions composed by f >> g or compose, calling it calls them in order
+	COMPOSED_OBJ ObjectType = "COMPOSED"
 )
 
 var (
@@ -43,6 +61,36 @@ func (i IntegerObject) Inspect() string {
 	return fmt.Sprint(i.Val)
 }
 
+// BigIntObject integer which overflowed int64, see NewBigInt
+type BigIntObject struct {
+	Val *big.Int
+}
+
+func (i BigIntObject) Type() ObjectType {
+	return BIGINT_OBJ
+}
+
+func (i BigIntObject) Inspect() string {
+	return i.Val.String()
+}
+
+type FloatObject struct {
+	Val float64
+}
+
+func (f FloatObject) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
+func (f FloatObject) Inspect() string {
+	str := strconv.FormatFloat(f.Val, 'g', -1, 64)
+	if !strings.ContainsAny(str, ".eIN") {
+		str += ".0"
+	}
+
+	return str
+}
+
 type BoolObject struct {
 	Val bool
 }
@@ -78,9 +126,13 @@ func (r ReturnObject) Inspect() string {
 }
 
 type FuncObject struct {
-	Args []parser.IdentifierExpression
+	Args []parser.Parameter
 	Body parser.BlockStatement
 	Env  *Environment
+	// ReturnType optional annotation of the result, checked only by the evaluator with type guards
+	ReturnType parser.TypeAnnotation
+	// Generator functions return the iterator over the yielded values
+	Generator bool
 }
 
 func (f FuncObject) Type() ObjectType {
@@ -102,7 +154,7 @@ func (f FuncObject) Inspect() string {
 	return buff.String()
 }
 
-func NewFuncObject(args []parser.IdentifierExpression, body parser.BlockStatement, env *Environment) FuncObject {
+func NewFuncObject(args []parser.Parameter, body parser.BlockStatement, env *Environment) FuncObject {
 	return FuncObject{
 		Args: args,
 		Body: body,
@@ -110,6 +162,53 @@ func NewFuncObject(args []parser.IdentifierExpression, body parser.BlockStatemen
 	}
 }
 
+// ComposedObject calls the first function with the arguments and each next one with the result of the previous
+type ComposedObject struct {
+	Funcs []Object
+}
+
+func (c ComposedObject) Type() ObjectType {
+	return COMPOSED_OBJ
+}
+
+func (c ComposedObject) Inspect() string {
+	funcs := make([]string, 0, len(c.Funcs))
+	for _, fn := range c.Funcs {
+		funcs = append(funcs, fn.Inspect())
+	}
+
+	return strings.Join(funcs, " >> ")
+}
+
+// IsCallable reports whether the value can be called like a function
+func IsCallable(obj Object) bool {
+	switch obj.Type() {
+	case FUNC_OBJ, BUILDIN_OBJ, STRUCT_TYPE_OBJ, VARIANT_OBJ, COMPOSED_OBJ:
+		return true
+	}
+
+	return false
+}
+
+// Compose returns the function calling the given ones from the first to the last, composed functions are
+// flattened, so (f >> g) >> h has the same functions as f >> (g >> h)
+func Compose(funcs ...Object) (ComposedObject, error) {
+	composed := ComposedObject{}
+	for _, fn := range funcs {
+		if !IsCallable(fn) {
+			return composed, fmt.Errorf("can not compose %s", fn.Type())
+		}
+
+		if c, ok := fn.(ComposedObject); ok {
+			composed.Funcs = append(composed.Funcs, c.Funcs...)
+		} else {
+			composed.Funcs = append(composed.Funcs, fn)
+		}
+	}
+
+	return composed, nil
+}
+
 type StringObject struct {
 	Val string
 }
@@ -122,8 +221,10 @@ func (str StringObject) Inspect() string {
 	return str.Val
 }
 
+// ArrayObject frozen arrays can not be modified
 type ArrayObject struct {
-	Val []Object
+	Val    []Object
+	Frozen bool
 }
 
 func (arr *ArrayObject) Type() ObjectType {
@@ -142,15 +243,97 @@ func (arr *ArrayObject) Inspect() string {
 	return buff.String()
 }
 
+// MapObject frozen maps can not be modified
 type MapObject struct {
-	Val map[Object]Object
+	Val    map[Object]Object
+	Frozen bool
+	// hashed keys compared by value by their hash, built on the first lookup of such key, so the map must be
+	// changed by Set and Delete once it is used
+	hashed map[string][]Object
 }
 
-func (mp MapObject) Type() ObjectType {
+func (mp *MapObject) Type() ObjectType {
 	return MAP_OBJ
 }
 
-func (mp MapObject) Inspect() string {
+// Get returns the value of the key equal to the given one, false if the key is missing
+func (mp *MapObject) Get(key Object) (Object, bool) {
+	val, ok := mp.Val[mp.Key(key)]
+	return val, ok
+}
+
+// Set assigns the value to the key equal to the given one
+func (mp *MapObject) Set(key, val Object) {
+	key = mp.Key(key)
+	if _, ok := mp.Val[key]; !ok {
+		if h, ok := keyHash(key); ok {
+			mp.hashed[h] = append(mp.hashed[h], key)
+		}
+	}
+
+	mp.Val[key] = val
+}
+
+// Delete removes the key equal to the given one and returns its value, false if the key is missing
+func (mp *MapObject) Delete(key Object) (Object, bool) {
+	key = mp.Key(key)
+	val, ok := mp.Val[key]
+	if !ok {
+		return nil, false
+	}
+
+	delete(mp.Val, key)
+	if h, ok := keyHash(key); ok {
+		mp.hashed[h] = slices.DeleteFunc(mp.hashed[h], func(k Object) bool { return k == key })
+	}
+
+	return val, true
+}
+
+// Key returns the key of the map equal to the given one. Keys compared by value, big integers and enum values,
+// are pointers, so the equal key already in the map is found by the hash of the value and returned instead of
+// the given one, other keys are returned as is.
+func (mp *MapObject) Key(key Object) Object {
+	h, ok := keyHash(key)
+	if !ok {
+		return key
+	}
+
+	if mp.hashed == nil {
+		mp.hashed = make(map[string][]Object)
+		for k := range mp.Val {
+			if kh, ok := keyHash(k); ok {
+				mp.hashed[kh] = append(mp.hashed[kh], k)
+			}
+		}
+	}
+
+	if _, ok := mp.Val[key]; ok {
+		return key
+	}
+
+	for _, k := range mp.hashed[h] {
+		if Equal(k, key) {
+			return k
+		}
+	}
+
+	return key
+}
+
+// keyHash returns the hash of the map key compared by value rather than by identity, false for the other keys
+func keyHash(key Object) (string, bool) {
+	switch v := key.(type) {
+	case BigIntObject:
+		return v.Val.String(), true
+	case *EnumObject:
+		return v.Inspect(), true
+	}
+
+	return "", false
+}
+
+func (mp *MapObject) Inspect() string {
 	var buff bytes.Buffer
 	buff.WriteString("{")
 	var elements []string
@@ -163,8 +346,232 @@ func (mp MapObject) Inspect() string {
 	return buff.String()
 }
 
+// StructType struct declared by struct Name { ... }, fields keep the order of the declaration
+type StructType struct {
+	Name    string
+	Fields  []parser.Field
+	Methods map[string]FuncObject
+}
+
+func (st *StructType) Type() ObjectType {
+	return STRUCT_TYPE_OBJ
+}
+
+func (st *StructType) Inspect() string {
+	fields := make([]string, 0, len(st.Fields))
+	for _, field := range st.Fields {
+		fields = append(fields, field.String())
+	}
+
+	return fmt.Sprintf("struct %s{%s}", st.Name, strings.Join(fields, ","))
+}
+
+// FieldIndex returns the position of the field in the declaration, false if the struct has no such field
+func (st *StructType) FieldIndex(name string) (int, bool) {
+	for i, field := range st.Fields {
+		if field.Name.Identifier.Literal == name {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// StructObject instance of the struct, values are stored in the order of the fields of the type. Frozen
+// structs can not be modified.
+type StructObject struct {
+	Def    *StructType
+	Val    []Object
+	Frozen bool
+}
+
+func (st *StructObject) Type() ObjectType {
+	return STRUCT_OBJ
+}
+
+func (st *StructObject) Inspect() string {
+	var buff bytes.Buffer
+	buff.WriteString(st.Def.Name)
+	buff.WriteString("{")
+	fields := make([]string, 0, len(st.Val))
+	for i, val := range st.Val {
+		fields = append(fields, fmt.Sprintf("%s:%s", st.Def.Fields[i].Name, val.Inspect()))
+	}
+
+	buff.WriteString(strings.Join(fields, ","))
+	buff.WriteString("}")
+	return buff.String()
+}
+
+// EnumType enum declared by enum Name { ... }, variants keep the order of the declaration
+type EnumType struct {
+	Name     string
+	Variants []*VariantType
+}
+
+func (en *EnumType) Type() ObjectType {
+	return ENUM_TYPE_OBJ
+}
+
+func (en *EnumType) Inspect() string {
+	variants := make([]string, 0, len(en.Variants))
+	for _, variant := range en.Variants {
+		variants = append(variants, variant.String())
+	}
+
+	return fmt.Sprintf("enum %s{%s}", en.Name, strings.Join(variants, ","))
+}
+
+// Variant returns the variant by name, false if the enum has no such variant
+func (en *EnumType) Variant(name string) (*VariantType, bool) {
+	for _, variant := range en.Variants {
+		if variant.Name == name {
+			return variant, true
+		}
+	}
+
+	return nil, false
+}
+
+// VariantType variant of the enum, variants with fields are called to create the value, variants without
+// fields are bound as values
+type VariantType struct {
+	Enum   *EnumType
+	Name   string
+	Fields []parser.Field
+	// origin declared variant of the copy made for another task, nil for the declared variant
+	origin *VariantType
+}
+
+// Same reports whether both variants are the same declared variant, variants of the values passed between
+// tasks are copies
+func (v *VariantType) Same(other *VariantType) bool {
+	return v.Origin() == other.Origin()
+}
+
+// Origin returns the declared variant
+func (v *VariantType) Origin() *VariantType {
+	if v.origin != nil {
+		return v.origin
+	}
+
+	return v
+}
+
+func (v *VariantType) Type() ObjectType {
+	return VARIANT_OBJ
+}
+
+func (v *VariantType) Inspect() string {
+	return v.Enum.Name + "." + v.String()
+}
+
+func (v *VariantType) String() string {
+	if len(v.Fields) == 0 {
+		return v.Name
+	}
+
+	fields := make([]string, 0, len(v.Fields))
+	for _, field := range v.Fields {
+		fields = append(fields, field.String())
+	}
+
+	return fmt.Sprintf("%s(%s)", v.Name, strings.Join(fields, ","))
+}
+
+// FieldIndex returns the position of the field in the declaration, false if the variant has no such field
+func (v *VariantType) FieldIndex(name string) (int, bool) {
+	for i, field := range v.Fields {
+		if field.Name.Identifier.Literal == name {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// EnumObject value of the enum tagged by its variant, values of the fields are stored in the order of the
+// declaration. Enum values are immutable.
+type EnumObject struct {
+	Variant *VariantType
+	Val     []Object
+}
+
+func (en *EnumObject) Type() ObjectType {
+	return ENUM_OBJ
+}
+
+func (en *EnumObject) Inspect() string {
+	if len(en.Variant.Fields) == 0 {
+		return en.Variant.Name
+	}
+
+	vals := make([]string, 0, len(en.Val))
+	for _, val := range en.Val {
+		vals = append(vals, val.Inspect())
+	}
+
+	return fmt.Sprintf("%s(%s)", en.Variant.Name, strings.Join(vals, ","))
+}
+
+// Equal compares values of scalar objects, numbers are compared by value regardless of their type. Enum
+// values are equal when they have the same variant and equal fields.
+func Equal(a, b Object) bool {
+	if IsNumber(a) && IsNumber(b) {
+		cmp, err := CompareNumbers(a, b)
+		return err == nil && cmp == 0
+	}
+
+	switch v := a.(type) {
+	case StringObject:
+		other, ok := b.(StringObject)
+		return ok && v.Val == other.Val
+	case BoolObject:
+		other, ok := b.(BoolObject)
+		return ok && v.Val == other.Val
+	case NilObject:
+		return b.Type() == NIL_OBJ
+	case *EnumObject:
+		other, ok := b.(*EnumObject)
+		if !ok || !v.Variant.Same(other.Variant) {
+			return false
+		}
+
+		for i := range v.Val {
+			if !Equal(v.Val[i], other.Val[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// ToBool converts the condition to bool, nil is false, numbers are true when they are positive
+func ToBool(obj Object) (BoolObject, error) {
+	switch v := obj.(type) {
+	case NilObject:
+		return FALSE, nil
+	case BoolObject:
+		return v, nil
+	case IntegerObject:
+		if v.Val >= 1 {
+			return TRUE, nil
+		}
+		return FALSE, nil
+	case BigIntObject:
+		return BoolObject{Val: v.Val.Sign() > 0}, nil
+	case FloatObject:
+		return BoolObject{Val: v.Val > 0}, nil
+	default:
+		return FALSE, fmt.Errorf("can not use %s as condition", obj.Type())
+	}
+}
+
 // BuildInFunc nodes like len, print
-type BuildInFunc func(args ...Object) (Object, error)
+type BuildInFunc func(rt *Runtime, args ...Object) (Object, error)
 
 func (b BuildInFunc) Inspect() string {
 	return "build in"
@@ -174,8 +581,13 @@ func (b BuildInFunc) Type() ObjectType {
 	return BUILDIN_OBJ
 }
 
+// BuildInNamespaces groups of build in functions exposed under a single name, e.g. math["abs"](-1)
+var BuildInNamespaces = map[string]Object{
+	"math": Math,
+}
+
 var BuildIns = map[string]BuildInFunc{
-	"len": func(args ...Object) (Object, error) {
+	"len": func(_ *Runtime, args ...Object) (Object, error) {
 		if len(args) != 1 {
 			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
 		}
@@ -185,16 +597,123 @@ var BuildIns = map[string]BuildInFunc{
 			return IntegerObject{Val: int64(len(v.Val))}, nil
 		case *ArrayObject:
 			return IntegerObject{Val: int64(len(v.Val))}, nil
+		case *RangeObject:
+			return IntegerObject{Val: v.Len()}, nil
+		case *ChannelObject:
+			return IntegerObject{Val: int64(v.Len())}, nil
 		default:
 			return nil, fmt.Errorf("unexpected argument type")
 		}
 	},
-	"print": func(args ...Object) (Object, error) {
+	// freeze makes arrays, maps and structs read-only, values of other types are immutable and returned as is.
+	// Nested arrays and maps are not frozen.
+	"freeze": func(_ *Runtime, args ...Object) (Object, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+
+		switch v := args[0].(type) {
+		case *ArrayObject:
+			v.Frozen = true
+		case *MapObject:
+			v.Frozen = true
+		case *StructObject:
+			v.Frozen = true
+		}
+
+		return args[0], nil
+	},
+	// iter returns the iterator over the array, string or map, iterators are returned as is
+	"iter": func(_ *Runtime, args ...Object) (Object, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+
+		it, err := Iterate(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return it, nil
+	},
+	// compose returns the function calling the given functions from the first to the last, same as f >> g >> h
+	"compose": func(_ *Runtime, args ...Object) (Object, error) {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("expected at least 1 argument, got 0")
+		}
+
+		return Compose(args...)
+	},
+	// channel creates the channel buffering the given number of values, channel() has no buffer
+	"channel": func(_ *Runtime, args ...Object) (Object, error) {
+		if len(args) == 0 {
+			return NewChannel(0), nil
+		}
+
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+
+		size, ok := args[0].(IntegerObject)
+		if !ok || size.Val < 0 || size.Val > math.MaxInt32 {
+			return nil, fmt.Errorf("buffer size must be a non negative integer, got %s", args[0].Inspect())
+		}
+
+		return NewChannel(int(size.Val)), nil
+	},
+	"print": needs(Stdout, func(_ *Runtime, args ...Object) (Object, error) {
 		for _, arg := range args {
 			io.WriteString(os.Stdout, arg.Inspect())
 			io.WriteString(os.Stdout, "\n")
 		}
 
 		return NIL, nil
-	},
+	}),
+	// now returns the number of milliseconds since the unix epoch
+	"now": needs(Clock, func(_ *Runtime, args ...Object) (Object, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("expected 0 arguments, got %d", len(args))
+		}
+
+		return IntegerObject{Val: time.Now().UnixMilli()}, nil
+	}),
+	// random returns the float from [0, 1), random(n) the integer from [0, n)
+	"random": needs(Random, func(_ *Runtime, args ...Object) (Object, error) {
+		if len(args) == 0 {
+			return FloatObject{Val: rand.Float64()}, nil
+		}
+
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+
+		n, ok := args[0].(IntegerObject)
+		if !ok || n.Val <= 0 {
+			return nil, fmt.Errorf("expected positive integer, got %s", args[0].Inspect())
+		}
+
+		return IntegerObject{Val: rand.Int63n(n.Val)}, nil
+	}),
+	"read_file":   needs(FileRead, readFile),
+	"write_file":  needs(FileWrite, writeFile(os.O_TRUNC)),
+	"append_file": needs(FileWrite, writeFile(os.O_APPEND)),
+	"list_dir":    needs(FileRead, listDir),
+	"exists":      needs(FileRead, exists),
+	"read_line":   needs(Stdin, readLine),
+	"read_lines":  needs(Stdin, readLines),
+	"env":         needs(EnvRead, env),
+	"args":        scriptArgs,
+	"exit":        exit,
+	"is_error":    isError,
+}
+
+// needs declares the capabilities used by the build in function, calls without them fail with PermissionError
+func needs(c Capability, fn BuildInFunc) BuildInFunc {
+	return func(rt *Runtime, args ...Object) (Object, error) {
+		if err := rt.Capabilities.Require(c); err != nil {
+			return nil, err
+		}
+
+		return fn(rt, args...)
+	}
 }
@@ -0,0 +1,175 @@
+package object
+
+import (
+	"fmt"
+	"math"
+)
+
+var RANGE_OBJ ObjectType = "RANGE"
+
+// RangeObject lazy sequence of integers from Start towards End by Step, End is included only by inclusive
+// ranges. Values are computed on demand, so the length of the range does not affect its size.
+type RangeObject struct {
+	Start     int64
+	End       int64
+	Step      int64
+	Inclusive bool
+}
+
+// NewRange creates the range with the step 1
+func NewRange(start, end int64, inclusive bool) *RangeObject {
+	return &RangeObject{
+		Start:     start,
+		End:       end,
+		Step:      1,
+		Inclusive: inclusive,
+	}
+}
+
+func (r *RangeObject) Type() ObjectType {
+	return RANGE_OBJ
+}
+
+func (r *RangeObject) Inspect() string {
+	operator := ".."
+	if r.Inclusive {
+		operator = "..="
+	}
+
+	if r.Step == 1 {
+		return fmt.Sprintf("%d%s%d", r.Start, operator, r.End)
+	}
+
+	return fmt.Sprintf("(%d%s%d).step(%d)", r.Start, operator, r.End, r.Step)
+}
+
+// span returns the distance from Start to End in the direction of the step and the absolute step, false
+// if the range is empty
+func (r *RangeObject) span() (uint64, uint64, bool) {
+	switch {
+	case r.Step > 0 && (r.End > r.Start || r.Inclusive && r.End == r.Start):
+		return uint64(r.End) - uint64(r.Start), uint64(r.Step), true
+	case r.Step < 0 && (r.End < r.Start || r.Inclusive && r.End == r.Start):
+		return uint64(r.Start) - uint64(r.End), -uint64(r.Step), true
+	}
+
+	return 0, 0, false
+}
+
+// Len returns the number of values of the range, ranges longer than the max int are cut to the max int
+func (r *RangeObject) Len() int64 {
+	span, step, ok := r.span()
+	if !ok {
+		return 0
+	}
+
+	n := span / step
+	if r.Inclusive || span%step != 0 {
+		n++
+	}
+
+	// the only case is the inclusive range over all integers
+	if n == 0 || n > math.MaxInt64 {
+		return math.MaxInt64
+	}
+
+	return int64(n)
+}
+
+// At returns the value at the index, the index must be less than Len
+func (r *RangeObject) At(i int64) int64 {
+	return int64(uint64(r.Start) + uint64(i)*uint64(r.Step))
+}
+
+// Contains reports whether the value is one of the values of the range
+func (r *RangeObject) Contains(val int64) bool {
+	span, step, ok := r.span()
+	if !ok {
+		return false
+	}
+
+	var distance uint64
+	switch {
+	case r.Step > 0 && val >= r.Start:
+		distance = uint64(val) - uint64(r.Start)
+	case r.Step < 0 && val <= r.Start:
+		distance = uint64(r.Start) - uint64(val)
+	default:
+		return false
+	}
+
+	if distance > span || distance == span && !r.Inclusive {
+		return false
+	}
+
+	return distance%step == 0
+}
+
+func rangeLen(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return nil, err
+	}
+
+	return IntegerObject{Val: args[0].(*RangeObject).Len()}, nil
+}
+
+// rangeStep returns the range with the step, ranges with negative steps count down: (10..0).step(-2)
+func rangeStep(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	step, ok := args[1].(IntegerObject)
+	if !ok {
+		return nil, fmt.Errorf("expected integer, got %s", args[1].Type())
+	}
+
+	if step.Val == 0 {
+		return nil, fmt.Errorf("step must not be zero")
+	}
+
+	r := *args[0].(*RangeObject)
+	r.Step = step.Val
+	return &r, nil
+}
+
+func rangeContains(_ *Runtime, args ...Object) (Object, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	r := args[0].(*RangeObject)
+	switch v := args[1].(type) {
+	case IntegerObject:
+		return BoolObject{Val: r.Contains(v.Val)}, nil
+	case FloatObject:
+		return BoolObject{Val: v.Val == math.Trunc(v.Val) && math.Abs(v.Val) < math.MaxInt64 && r.Contains(int64(v.Val))}, nil
+	}
+
+	return FALSE, nil
+}
+
+// rangeIterator adapts the method of iterators to ranges, the range is iterated from the start
+func rangeIterator(method BuildInMethod) BuildInMethod {
+	return func(rt *Runtime, args ...Object) (Object, error) {
+		it, err := Iterate(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return method(rt, append([]Object{it}, args[1:]...)...)
+	}
+}
+
+func rangeIter(r *RangeObject) *IteratorObject {
+	n := r.Len()
+	i := int64(0)
+	return NewIterator("range", func() (Object, bool, error) {
+		if i >= n {
+			return nil, false, nil
+		}
+
+		i++
+		return IntegerObject{Val: r.At(i - 1)}, true, nil
+	}, nil)
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/charkpep/yami/src/lexer"
+	"math/big"
+	"strconv"
 	"strings"
 )
 
@@ -42,8 +44,66 @@ func (i IntegerExpression) String() string {
 	return fmt.Sprintf("%d", i.Val)
 }
 
+// BigIntegerExpression integer literal which does not fit into int64
+type BigIntegerExpression struct {
+	token lexer.Token
+	Val   *big.Int
+}
+
+func (i BigIntegerExpression) Token() lexer.Token {
+	return i.token
+}
+
+func (i BigIntegerExpression) expression() {}
+
+func (i BigIntegerExpression) String() string {
+	return i.Val.String()
+}
+
+type FloatExpression struct {
+	token lexer.Token
+	Val   float64
+}
+
+func (f FloatExpression) Token() lexer.Token {
+	return f.token
+}
+
+func (f FloatExpression) expression() {}
+
+func (f FloatExpression) String() string {
+	return strconv.FormatFloat(f.Val, 'f', -1, 64)
+}
+
+// Resolution location of the variable filled by the resolver. Depth is the number of scopes between the scope
+// using the variable and the scope defining it, Slot is the position of the variable in the defining scope.
+// Unresolved variables, e.g. globals, are looked up by name.
+type Resolution struct {
+	Resolved bool
+	Depth    int
+	Slot     int
+}
+
 type IdentifierExpression struct {
 	Identifier lexer.Token
+	// Resolution is shared by the copies of the node, nil for nodes created outside the parser
+	Resolution *Resolution
+}
+
+func NewIdentifier(token lexer.Token) IdentifierExpression {
+	return IdentifierExpression{
+		Identifier: token,
+		Resolution: &Resolution{},
+	}
+}
+
+// Resolved returns the resolution of the identifier, false if the identifier has to be looked up by name
+func (i IdentifierExpression) Resolved() (Resolution, bool) {
+	if i.Resolution == nil || !i.Resolution.Resolved {
+		return Resolution{}, false
+	}
+
+	return *i.Resolution, true
 }
 
 func (i IdentifierExpression) Token() lexer.Token {
@@ -52,6 +112,8 @@ func (i IdentifierExpression) Token() lexer.Token {
 
 func (i IdentifierExpression) expression() {}
 
+func (i IdentifierExpression) pattern() {}
+
 func (i IdentifierExpression) String() string {
 	return i.Identifier.Literal
 }
@@ -118,10 +180,51 @@ func (i IfExpression) String() string {
 	return buff.String()
 }
 
+// Parameter of the function, either a pattern with optional default value or a rest parameter ...name
+// collecting the remaining positional arguments into an array
+type Parameter struct {
+	Pattern Pattern
+	Default Expression
+	Rest    bool
+	// Type optional annotation, the type of the rest parameter describes the array of the remaining arguments
+	Type TypeAnnotation
+}
+
+// Name returns the name of the parameter used by named arguments, parameters destructuring a value have no name
+func (p Parameter) Name() (string, bool) {
+	ident, ok := p.Pattern.(IdentifierExpression)
+	if !ok {
+		return "", false
+	}
+
+	return ident.Identifier.Literal, true
+}
+
+func (p Parameter) String() string {
+	param := p.Pattern.String()
+	if p.Rest {
+		param = "..." + param
+	}
+
+	if p.Type != nil {
+		param += ": " + p.Type.String()
+	}
+
+	if p.Default != nil {
+		param += " = " + p.Default.String()
+	}
+
+	return param
+}
+
 type FuncExpression struct {
 	token lexer.Token
-	Args  []IdentifierExpression
+	Args  []Parameter
 	Body  BlockStatement
+	// ReturnType optional annotation, nil if the type is not specified
+	ReturnType TypeAnnotation
+	// Generator is set for functions containing yield, calling them returns the iterator over yielded values
+	Generator bool
 }
 
 func (f FuncExpression) Token() lexer.Token {
@@ -142,6 +245,12 @@ func (f FuncExpression) String() string {
 	}
 
 	buff.WriteString(") ")
+	if f.ReturnType != nil {
+		buff.WriteString("-> ")
+		buff.WriteString(f.ReturnType.String())
+		buff.WriteString(" ")
+	}
+
 	buff.WriteString(f.Body.String())
 	return buff.String()
 }
@@ -172,6 +281,38 @@ func (c CallExpression) String() string {
 	return buff.String()
 }
 
+// SpreadExpression ...arr expands the array into positional arguments of the call
+type SpreadExpression struct {
+	token lexer.Token
+	Val   Expression
+}
+
+func (s SpreadExpression) Token() lexer.Token {
+	return s.token
+}
+
+func (s SpreadExpression) expression() {}
+
+func (s SpreadExpression) String() string {
+	return "..." + s.Val.String()
+}
+
+// NamedArgument name: value passes the value to the parameter with the same name
+type NamedArgument struct {
+	Name IdentifierExpression
+	Val  Expression
+}
+
+func (n NamedArgument) Token() lexer.Token {
+	return n.Name.Token()
+}
+
+func (n NamedArgument) expression() {}
+
+func (n NamedArgument) String() string {
+	return n.Name.String() + ": " + n.Val.String()
+}
+
 type AssignExpression struct {
 	token      lexer.Token
 	Identifier Expression
@@ -185,9 +326,38 @@ func (ass AssignExpression) Token() lexer.Token {
 func (ass AssignExpression) expression() {}
 
 func (ass AssignExpression) String() string {
+	if operator, ok := ass.Operator(); ok {
+		return fmt.Sprintf("%s%s=%s", ass.Identifier, operator.Literal, ass.Val)
+	}
+
 	return fmt.Sprintf("%s=%s", ass.Identifier, ass.Val)
 }
 
+var compoundAssignments = map[lexer.TokenType]lexer.TokenType{
+	lexer.PLUS_ASSIGN:     lexer.PLUS,
+	lexer.HYPHEN_ASSIGN:   lexer.HYPHEN,
+	lexer.ASTERISK_ASSIGN: lexer.ASTERISK,
+	lexer.SLASH_ASSIGN:    lexer.SLASH,
+	lexer.PERCENT_ASSIGN:  lexer.PERCENT,
+	lexer.BAND_ASSIGN:     lexer.BAND,
+	lexer.BOR_ASSIGN:      lexer.BOR,
+	lexer.BLSHIFT_ASSIGN:  lexer.BLSHIFT,
+	lexer.BRSHIFT_ASSIGN:  lexer.BRSHIFT,
+}
+
+// Operator returns binary operator of the compound assignment, e.g. + for +=, false for plain assignment
+func (ass AssignExpression) Operator() (lexer.Token, bool) {
+	operator, ok := compoundAssignments[ass.token.Token]
+	if !ok {
+		return lexer.Token{}, false
+	}
+
+	tok := ass.token
+	tok.Token = operator
+	tok.Literal = strings.TrimSuffix(tok.Literal, "=")
+	return tok, true
+}
+
 type BoolExpression struct {
 	token lexer.Token
 	Val   bool
@@ -240,6 +410,75 @@ func (idx IndexExpression) String() string {
 	return buff.String()
 }
 
+// MemberExpression p.x reads the field of the struct, on maps it is the same as m["x"]
+type MemberExpression struct {
+	token lexer.Token
+	Of    Expression
+	Field IdentifierExpression
+}
+
+func (m MemberExpression) Token() lexer.Token {
+	return m.token
+}
+
+func (m MemberExpression) expression() {}
+
+func (m MemberExpression) String() string {
+	return m.Of.String() + "." + m.Field.String()
+}
+
+// StructExpression Point{x: 1, y: 2} creates the struct with the given fields
+type StructExpression struct {
+	Name   IdentifierExpression
+	Fields []NamedArgument
+}
+
+func (s StructExpression) Token() lexer.Token {
+	return s.Name.Token()
+}
+
+func (s StructExpression) expression() {}
+
+func (s StructExpression) String() string {
+	fields := make([]string, 0, len(s.Fields))
+	for _, field := range s.Fields {
+		fields = append(fields, field.String())
+	}
+
+	return s.Name.String() + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// SliceExpression a[start:end], both bounds are optional: a[:end], a[start:]
+type SliceExpression struct {
+	token lexer.Token
+	Of    Expression
+	Start Expression
+	End   Expression
+}
+
+func (s SliceExpression) Token() lexer.Token {
+	return s.token
+}
+
+func (s SliceExpression) expression() {}
+
+func (s SliceExpression) String() string {
+	var buff bytes.Buffer
+	buff.WriteString(s.Of.String())
+	buff.WriteString("[")
+	if s.Start != nil {
+		buff.WriteString(s.Start.String())
+	}
+
+	buff.WriteString(":")
+	if s.End != nil {
+		buff.WriteString(s.End.String())
+	}
+
+	buff.WriteString("]")
+	return buff.String()
+}
+
 type ArrayExpression struct {
 	Arr   []Expression
 	token lexer.Token
@@ -300,3 +539,95 @@ func (n NilExpression) expression() {}
 func (n NilExpression) String() string {
 	return "nil"
 }
+
+// PipeExpression xs |> map(f) passes the value on the left as the first argument of the call on the right,
+// a function on the right is called with the value: x |> f is f(x)
+type PipeExpression struct {
+	token lexer.Token
+	Left  Expression
+	Right Expression
+}
+
+func (p PipeExpression) Token() lexer.Token {
+	return p.token
+}
+
+func (p PipeExpression) expression() {}
+
+func (p PipeExpression) String() string {
+	return fmt.Sprintf("(%s |> %s)", p.Left.String(), p.Right.String())
+}
+
+// Call returns the call the pipe stands for, f(x, a) for x |> f(a) and f(x) for x |> f
+func (p PipeExpression) Call() CallExpression {
+	call, ok := p.Right.(CallExpression)
+	if !ok {
+		return CallExpression{token: p.token, Call: p.Right, CallArgs: []Expression{p.Left}}
+	}
+
+	return CallExpression{token: call.token, Call: call.Call, CallArgs: append([]Expression{p.Left}, call.CallArgs...)}
+}
+
+// MethodCall returns x.f(a) for x |> f(a), false if the right side is not a call of a name
+func (p PipeExpression) MethodCall() (CallExpression, bool) {
+	call, ok := p.Right.(CallExpression)
+	if !ok {
+		return CallExpression{}, false
+	}
+
+	name, ok := call.Call.(IdentifierExpression)
+	if !ok {
+		return CallExpression{}, false
+	}
+
+	member := MemberExpression{token: p.token, Of: p.Left, Field: name}
+	return CallExpression{token: call.token, Call: member, CallArgs: call.CallArgs}, true
+}
+
+// SpawnExpression spawn f(args) evaluates the call on its own goroutine and returns the task
+type SpawnExpression struct {
+	token lexer.Token
+	Call  CallExpression
+}
+
+func (s SpawnExpression) Token() lexer.Token {
+	return s.token
+}
+
+func (s SpawnExpression) expression() {}
+
+func (s SpawnExpression) String() string {
+	return "spawn " + s.Call.String()
+}
+
+// AwaitExpression await task waits until the task finishes and returns its result
+type AwaitExpression struct {
+	token lexer.Token
+	Task  Expression
+}
+
+func (a AwaitExpression) Token() lexer.Token {
+	return a.token
+}
+
+func (a AwaitExpression) expression() {}
+
+func (a AwaitExpression) String() string {
+	return "await " + a.Task.String()
+}
+
+// YieldExpression yield value suspends the generator until the next value is requested
+type YieldExpression struct {
+	token lexer.Token
+	Val   Expression
+}
+
+func (y YieldExpression) Token() lexer.Token {
+	return y.token
+}
+
+func (y YieldExpression) expression() {}
+
+func (y YieldExpression) String() string {
+	return "yield " + y.Val.String()
+}
@@ -15,6 +15,8 @@ const (
 	OR // ==, !=, &&, ||
 	AND
 	RELATIONAL // < >, <= or >=, ==, !=
+	PIPE       // xs |> len() > 0 is (xs |> len()) > 0
+	RANGE      // 0..n - 1 is 0..(n - 1)
 	BIN_OR
 	XOR
 	BIN_AND
@@ -22,6 +24,7 @@ const (
 	ADDITION // +
 	MULTIPLICATION
 	PREFIX // -5 !5
+	POWER  // 2 ** 3, binds tighter than prefix so -2 ** 2 is -(2 ** 2)
 	IDX    // a[1]
 	CALL   // func()
 )
@@ -84,6 +87,14 @@ type Parser struct {
 	curToken  lexer.Token
 	peekToken lexer.Token
 	Errors    []ParsingError
+
+	// noStructLiteral is set while parsing the condition of if and the value of match, where Name { starts
+	// the body rather than the struct literal
+	noStructLiteral bool
+	// noArrowLambda is set while parsing the guard of the match arm, where name => starts the body of the arm
+	noArrowLambda bool
+	// yields is set when yield is parsed, functions containing yield are generators
+	yields bool
 }
 
 func (p *Parser) registerPrefixFunc(token lexer.TokenType, fn prefixParseFn) {
@@ -116,20 +127,35 @@ func NewParserFromLexer(lex lexer.TokenReader) *Parser {
 	p.registerPrefixFunc(lexer.IDENT, p.parseIdentifier)
 	p.registerPrefixFunc(lexer.BLEFT, p.ParseGroupedExpression)
 	p.registerPrefixFunc(lexer.NUMBER, p.ParseNumber)
+	p.registerPrefixFunc(lexer.FLOAT, p.ParseFloat)
 	p.registerPrefixFunc(lexer.BANG, p.ParsePrefix)
 	p.registerPrefixFunc(lexer.HYPHEN, p.ParsePrefix)
+	p.registerPrefixFunc(lexer.BNOT, p.ParsePrefix)
 	p.registerPrefixFunc(lexer.IF, p.parseIfExpression)
+	p.registerPrefixFunc(lexer.MATCH, p.parseMatchExpression)
 	p.registerPrefixFunc(lexer.FUNC, p.ParseFuncExpression)
+	p.registerPrefixFunc(lexer.BOR, p.parseLambda)
+	p.registerPrefixFunc(lexer.OR, p.parseLambda)
+	p.registerPrefixFunc(lexer.YIELD, p.parseYieldExpression)
+	p.registerPrefixFunc(lexer.SPAWN, p.parseSpawnExpression)
+	p.registerPrefixFunc(lexer.AWAIT, p.parseAwaitExpression)
+	p.registerPrefixFunc(lexer.SELECT, p.parseSelectExpression)
 	p.registerInfixFunc(lexer.BLEFT, p.parseCallExpression)
-	p.registerInfixFunc(lexer.ASSIGN, p.parseAssignExpression)
+	p.registerInfixesFunc(p.parseAssignExpression, lexer.ASSIGN, lexer.PLUS_ASSIGN, lexer.HYPHEN_ASSIGN,
+		lexer.ASTERISK_ASSIGN, lexer.SLASH_ASSIGN, lexer.PERCENT_ASSIGN, lexer.BAND_ASSIGN, lexer.BOR_ASSIGN,
+		lexer.BLSHIFT_ASSIGN, lexer.BRSHIFT_ASSIGN)
 	p.registerPrefixFunc(lexer.SBLEFT, p.parseArrayExpression)
 	p.registerPrefixFunc(lexer.TRUE, p.parseBoolExpression)
 	p.registerPrefixFunc(lexer.FALSE, p.parseBoolExpression)
 	p.registerPrefixFunc(lexer.STRING, p.parseStringExpression)
+	p.registerPrefixFunc(lexer.NIL, p.parseNilExpression)
 	p.registerPrefixFunc(lexer.BRLEFT, p.parseHashMap)
 	p.registerInfixFunc(lexer.SBLEFT, p.parseIndexExpression)
+	p.registerInfixFunc(lexer.DOT, p.parseMemberExpression)
+	p.registerInfixFunc(lexer.PIPE, p.parsePipeExpression)
 	p.registerInfixesFunc(p.ParseInfix, lexer.PLUS, lexer.HYPHEN, lexer.SLASH, lexer.ASTERISK, lexer.EQ, lexer.NEQ,
-		lexer.OR, lexer.AND, lexer.GT, lexer.GTE, lexer.LT, lexer.LTE, lexer.BOR, lexer.BAND, lexer.BLSHIFT, lexer.BRSHIFT)
+		lexer.OR, lexer.AND, lexer.GT, lexer.GTE, lexer.LT, lexer.LTE, lexer.BOR, lexer.BAND, lexer.BLSHIFT, lexer.BRSHIFT,
+		lexer.PERCENT, lexer.BXOR, lexer.DASTERISK, lexer.DOTDOT, lexer.DOTDOTEQ)
 
 	return p
 }
@@ -173,10 +199,16 @@ func (p *Parser) parseStatement() (Statement, error) {
 		err error
 	)
 	switch p.curToken.Token {
-	case lexer.LET:
+	case lexer.LET, lexer.CONST:
 		st, err = p.parseLet()
 	case lexer.RETURN:
 		st, err = p.parseReturnStatement()
+	case lexer.STRUCT:
+		st, err = p.parseStructStatement()
+	case lexer.ENUM:
+		st, err = p.parseEnumStatement()
+	case lexer.FOR:
+		st, err = p.parseForStatement()
 	case lexer.BRLEFT:
 		st, err = p.parseBlockStatement()
 	case lexer.SCOLON:
@@ -225,6 +257,12 @@ func (p *Parser) parseExpression(precedence int) (Expression, error) {
 		return nil, err
 	}
 
+	return p.parseInfixes(left, precedence)
+}
+
+// parseInfixes continues the expression starting with the already parsed left operand
+func (p *Parser) parseInfixes(left Expression, precedence int) (Expression, error) {
+	var err error
 	for !p.isCurToken(lexer.SCOLON) && precedence < p.precedence(p.peekToken.Token) {
 		p.read()
 		infix, ok := p.infixParseFn[p.curToken.Token]
@@ -243,7 +281,8 @@ func (p *Parser) parseExpression(precedence int) (Expression, error) {
 
 func (p *Parser) precedence(token lexer.TokenType) int {
 	switch token {
-	case lexer.ASSIGN:
+	case lexer.ASSIGN, lexer.PLUS_ASSIGN, lexer.HYPHEN_ASSIGN, lexer.ASTERISK_ASSIGN, lexer.SLASH_ASSIGN,
+		lexer.PERCENT_ASSIGN, lexer.BAND_ASSIGN, lexer.BOR_ASSIGN, lexer.BLSHIFT_ASSIGN, lexer.BRSHIFT_ASSIGN:
 		return ASSIGN
 	case lexer.OR:
 		return OR
@@ -251,19 +290,27 @@ func (p *Parser) precedence(token lexer.TokenType) int {
 		return AND
 	case lexer.LT, lexer.LTE, lexer.GTE, lexer.GT, lexer.EQ, lexer.NEQ:
 		return RELATIONAL
+	case lexer.PIPE:
+		return PIPE
+	case lexer.DOTDOT, lexer.DOTDOTEQ:
+		return RANGE
 	case lexer.BAND:
 		return BIN_AND
 	case lexer.BOR:
 		return BIN_OR
+	case lexer.BXOR:
+		return XOR
 	case lexer.BLSHIFT, lexer.BRSHIFT:
 		return BIN_SHIFT
 	case lexer.PLUS, lexer.HYPHEN:
 		return ADDITION
-	case lexer.SLASH, lexer.ASTERISK:
+	case lexer.SLASH, lexer.ASTERISK, lexer.PERCENT:
 		return MULTIPLICATION
+	case lexer.DASTERISK:
+		return POWER
 	case lexer.BANG:
 		return PREFIX
-	case lexer.SBLEFT:
+	case lexer.SBLEFT, lexer.DOT:
 		return IDX
 	case lexer.BLEFT:
 		return CALL
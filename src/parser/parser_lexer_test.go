@@ -104,7 +104,12 @@ func AssertNodes(t *testing.T, a, b Node) bool {
 			return false
 		}
 		for i, _ := range v.Args {
-			if !AssertNodes(t, v.Args[i], b.(FuncExpression).Args[i]) {
+			if v.Args[i].Rest != b.(FuncExpression).Args[i].Rest {
+				t.Errorf("rest parameters does not match\n")
+				return false
+			}
+
+			if !AssertNodes(t, v.Args[i].Pattern, b.(FuncExpression).Args[i].Pattern) {
 				return false
 			}
 		}
@@ -681,19 +686,19 @@ func TestAstTreeWithConcreteLexer(t *testing.T) {
 								Token:   lexer.FUNC,
 								Literal: "fn",
 							},
-							Args: []IdentifierExpression{
-								{
+							Args: []Parameter{
+								{Pattern: IdentifierExpression{
 									Identifier: lexer.Token{
 										Token:   lexer.IDENT,
 										Literal: "a",
 									},
-								},
-								{
+								}},
+								{Pattern: IdentifierExpression{
 									Identifier: lexer.Token{
 										Token:   lexer.IDENT,
 										Literal: "b",
 									},
-								},
+								}},
 							},
 							Body: BlockStatement{
 								token: lexer.Token{
@@ -845,19 +850,19 @@ func TestAstTreeWithConcreteLexer(t *testing.T) {
 							token: lexer.Token{},
 							Call: FuncExpression{
 								token: lexer.Token{},
-								Args: []IdentifierExpression{
-									{
+								Args: []Parameter{
+									{Pattern: IdentifierExpression{
 										Identifier: lexer.Token{
 											Token:   lexer.IDENT,
 											Literal: "a",
 										},
-									},
-									{
+									}},
+									{Pattern: IdentifierExpression{
 										Identifier: lexer.Token{
 											Token:   lexer.IDENT,
 											Literal: "b",
 										},
-									},
+									}},
 								},
 								Body: BlockStatement{},
 							},
@@ -1161,7 +1166,8 @@ func TestAstTreeWithConcreteLexer(t *testing.T) {
 			},
 		},
 		{
-			``
+			``,
+			&RootNode{},
 		},
 	}
 
@@ -635,3 +635,138 @@ func TestIndexExpression(t *testing.T) {
 	}
 
 }
+
+func TestPatternErrors(t *testing.T) {
+	ts := []string{
+		"let [...a, b] = x",
+		"match x { [a] | [b] => 1 }",
+		"let {1} = x",
+		"fn(...a, b) {}",
+		"let x: = 1",
+		"let x: array<int = 1",
+		"fn(x) -> {}",
+		"fn(a = 1, b) {}",
+		"fn(...[a]) {}",
+		"f(a: 1, 2)",
+		"struct P { x, x }",
+		"struct { x }",
+		"struct point { x }",
+		"struct P { 1 }",
+		"p.1",
+		"Point{x}",
+		"let x = 1; x.y.1 = 2",
+		"struct P { fn m() {} }",
+		"struct P { fn m(self) {}, fn m(self) {} }",
+		"struct P { x, fn x(self) {} }",
+		"struct P { fn (self) {} }",
+		"enum result { Ok }",
+		"enum R { ok }",
+		"enum R { A, A }",
+		"enum R { A(x, x) }",
+		"enum R { A(1) }",
+		"match x { Some(a b) => 1 }",
+		"for x xs { x }",
+		"for x in xs x",
+		"fn() { yield }",
+		"|a, b",
+		"|a| ",
+		"x => ;",
+		"spawn f",
+		"select { 1 => 2 }",
+		"select { x.recv() => 1 }",
+		"select { x in => 1 }",
+		"select { _ => 1, _ => 2 }",
+	}
+
+	for i, test := range ts {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			p := NewParser(bytes.NewBufferString(test))
+			if _, err := p.Parse(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(p.Errors) == 0 {
+				t.Errorf("expected parsing error for %q", test)
+			}
+		})
+	}
+}
+
+func TestOperatorPrecedence(t *testing.T) {
+	type tt struct {
+		i string
+		o string
+	}
+
+	ts := []tt{
+		{"2 ** 3 ** 2", "(2 ** (3 ** 2))\n"},
+		{"-2 ** 2", "-((2 ** 2))\n"},
+		{"a * b % c", "((a * b) % c)\n"},
+		{"a | b ^ c & d", "(a | (b ^ (c & d)))\n"},
+		{"~a + 1", "(~(a) + 1)\n"},
+		{"a += b * 2", "a+=(b * 2)\n"},
+		{"a[1] <<= 2", "a[1]<<=2\n"},
+		{"0..n - 1", "(0 .. (n - 1))\n"},
+		{"a..=b == c", "((a ..= b) == c)\n"},
+		{"xs |> f(1) |> g", "((xs |> f(1)) |> g)\n"},
+		{"a + b |> len() > 0 && c", "((((a + b) |> len()) > 0) && c)\n"},
+		{"0..n |> f >> g", "((0 .. n) |> (f >> g))\n"},
+		{"a[1:2]", "a[1:2]\n"},
+		{"a[:n - 1]", "a[:(n - 1)]\n"},
+		{"a[-1:]", "a[-(1):]\n"},
+		{"a[:]", "a[:]\n"},
+		{"if a { 1 } else if b { 2 } else { 3 }", "if a { {\n1} } else {{\nif b { {\n2} } else {{\n3}}\n}}\n\n"},
+		{"let [a, [b], ...c] = x", "let [a, [b], ...c]=x;\n"},
+		{`let {name, age: years, "k": [v]} = x`, "let {name, \"age\": years, \"k\": [v]}=x;\n"},
+		{"fn([a, b], {c}, d) {}", "fn ([a, b],{c},d) {\n}\n"},
+		{"fn(x, step = 1, ...rest) {}", "fn (x,step = 1,...rest) {\n}\n"},
+		{"const [a, b] = x", "const [a, b]=x;\n"},
+		{"let n: int = 10", "let n: int=10;\n"},
+		{"let m: map<string, array<int>> = x", "let m: map<string, array<int>>=x;\n"},
+		{"let v: int | nil = nil", "let v: int | nil=nil;\n"},
+		{"fn(x: int, y: string = \"a\", ...rest: array<int | nil>) -> bool {}", "fn (x: int,y: string = \"a\",...rest: array<int | nil>) -> bool {\n}\n"},
+		{"fn(f: fn) -> map<string, array<array<int>>> { 1 }", "fn (f: fn) -> map<string, array<array<int>>> {\n1}\n"},
+		{"f(1, ...xs, step: 2 + 1)", "f(1,...xs,step: (2 + 1))\n"},
+		{"struct Point { x, y: int }", "struct Point { x, y: int }\n"},
+		{"p.x.y + 1", "(p.x.y + 1)\n"},
+		{"a.b[0].c = -p.x", "a.b[0].c=-(p.x)\n"},
+		{"Point{x: 1, y: f(2)}.x * 2", "(Point{x: 1, y: f(2)}.x * 2)\n"},
+		{"if P { 1 }", "if P { {\n1} }\n\n"},
+		{"if (P{x: 1}.x > 0) { 1 }", "if (P{x: 1}.x > 0) { {\n1} }\n\n"},
+		{"match P { _ => Q{} }", "match P {\n_ => {\nQ{}},\n}\n"},
+		{"struct P { x, fn get(self) { self.x } fn set(self, x) { self.x = x } }", "struct P { x, fn get(self) {\nself.x}, fn set(self,x) {\nself.x=x} }\n"},
+		{"a.b(1).c(2)", "a.b(1).c(2)\n"},
+		{"enum Result { Ok(value), Err(message: string), None }", "enum Result { Ok(value), Err(message: string), None }\n"},
+		{"match r { Ok([a, _]) | Err(a) => a, None => 0 }", "match r {\nOk([a, _]) | Err(a) => {\na},\nNone => {\n0},\n}\n"},
+		{"const MAX = 1; let Some(x) = y", "const MAX=1;\nlet Some(x)=y;\n"},
+		{"for [k, v] in m.iter() { yield k + v }", "for [k, v] in m.iter() {\nyield (k + v)}\n"},
+		{"for P in Q { 1 }", "for P in Q {\n1}\n"},
+		{"xs.map(|x| x * 2)", "xs.map(fn (x) {\n(x * 2)})\n"},
+		{"let f = |a, b: int = 1 + 1| { a + b }", "let f=fn (a,b: int = (1 + 1)) {\n(a + b)};\n"},
+		{"xs |> filter(x => x > 0 && x < 9)", "(xs |> filter(fn (x) {\n((x > 0) && (x < 9))}))\n"},
+		{"|| a || b", "fn () {\n(a || b)}\n"},
+		{"match x { n if ok => n }", "match x {\nn if ok => {\nn},\n}\n"},
+		{"let t = spawn f(1) + 1; await t", "let t=(spawn f(1) + 1);\nawait t\n"},
+		{"select { [a, b] in ch => a, out.send(x + 1) => { 1 } _ => x => x }", "select {\n[a, b] in ch => {\na},\nout.send((x + 1)) => {\n1},\n_ => {\nfn (x) {\nx}},\n}\n"},
+		{"-x.abs() + 1", "(-(x.abs()) + 1)\n"},
+		{`match x { 1 | 2 => "a", -1..=5 if y => { "b" } _ => "c" }`, "match x {\n1 | 2 => {\n\"a\"},\n-(1)..=5 if y => {\n\"b\"},\n_ => {\n\"c\"},\n}\n"},
+	}
+
+	for i, test := range ts {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			p := NewParser(bytes.NewBufferString(test.i))
+			root, err := p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(p.Errors) != 0 {
+				t.Fatal(p.Errors)
+			}
+
+			if root.String() != test.o {
+				t.Errorf("expected %q, got %q", test.o, root.String())
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"github.com/charkpep/yami/src/lexer"
+	"math/big"
 	"strconv"
 )
 
@@ -14,6 +16,18 @@ func (p *Parser) ParseNumber() (Expression, error) {
 
 	var err error
 	it.Val, err = strconv.ParseInt(token.Literal, 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		val, ok := new(big.Int).SetString(token.Literal, 10)
+		if !ok {
+			return nil, NewParsingError("invalid integer literal", token)
+		}
+
+		return BigIntegerExpression{
+			token: token,
+			Val:   val,
+		}, nil
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -21,14 +35,62 @@ func (p *Parser) ParseNumber() (Expression, error) {
 	return it, nil
 }
 
+func (p *Parser) ParseFloat() (Expression, error) {
+	token := p.curToken
+	fl := FloatExpression{
+		token: token,
+	}
+
+	var err error
+	fl.Val, err = strconv.ParseFloat(token.Literal, 64)
+	if err != nil {
+		return nil, NewParsingError("invalid float literal", token)
+	}
+
+	return fl, nil
+}
+
 func (p *Parser) parseIdentifier() (Expression, error) {
 	literal := p.curToken
 	if literal.Token != lexer.IDENT {
 		return nil, NewParsingError("expected Identifier", p.curToken)
 	}
-	return IdentifierExpression{
-		Identifier: literal,
-	}, nil
+
+	if p.peekToken.Token == lexer.BRLEFT && !p.noStructLiteral && isTypeName(literal.Literal) {
+		return p.parseStructExpression()
+	}
+
+	if p.peekToken.Token == lexer.FATARROW && !p.noArrowLambda {
+		return p.parseArrowLambda()
+	}
+
+	return NewIdentifier(literal), nil
+}
+
+// isTypeName reports whether the name starts with the upper case letter, only such names followed by { are
+// parsed as the struct literal, so x { ... } is still an expression followed by the block
+func isTypeName(name string) bool {
+	return name != "" && name[0] >= 'A' && name[0] <= 'Z'
+}
+
+// allowStructLiterals sets whether Name { starts the struct literal and returns the function restoring
+// the previous setting. Struct literals are allowed again inside brackets, e.g. if (Point{x: 1}).x { ... }
+func (p *Parser) allowStructLiterals(allowed bool) func() {
+	previous := p.noStructLiteral
+	p.noStructLiteral = !allowed
+	return func() {
+		p.noStructLiteral = previous
+	}
+}
+
+// allowArrowLambdas sets whether name => starts the lambda and returns the function restoring the previous
+// setting, lambdas are allowed again inside calls and blocks
+func (p *Parser) allowArrowLambdas(allowed bool) func() {
+	previous := p.noArrowLambda
+	p.noArrowLambda = !allowed
+	return func() {
+		p.noArrowLambda = previous
+	}
 }
 
 func (p *Parser) ParseInfix(expression Expression) (Expression, error) {
@@ -39,6 +101,11 @@ func (p *Parser) ParseInfix(expression Expression) (Expression, error) {
 
 	var err error
 	precedence := p.precedence(p.curToken.Token)
+	if p.isCurToken(lexer.DASTERISK) {
+		// right associative, 2 ** 3 ** 2 is 2 ** (3 ** 2)
+		precedence--
+	}
+
 	p.read()
 	infix.Right, err = p.parseExpression(precedence)
 	return &infix, err
@@ -56,6 +123,7 @@ func (p *Parser) ParsePrefix() (Expression, error) {
 }
 
 func (p *Parser) ParseGroupedExpression() (Expression, error) {
+	defer p.allowStructLiterals(true)()
 
 	p.read()
 	g, err := p.parseExpression(LOWEST)
@@ -75,15 +143,21 @@ func (p *Parser) parseLet() (Statement, error) {
 	literal := p.curToken
 	statement := LetStatement{
 		Literal: literal,
+		Const:   literal.Token == lexer.CONST,
 	}
 
 	p.read()
-	identifier, err := p.parseIdentifier()
+	var err error
+	statement.Identifier, err = p.parseBinding()
+	if err != nil {
+		return nil, err
+	}
+
+	statement.Type, err = p.parseOptionalType()
 	if err != nil {
 		return nil, err
 	}
 
-	statement.Identifier = identifier.(IdentifierExpression)
 	p.read()
 	if p.curToken.Token != lexer.ASSIGN {
 		return nil, NewParsingError("invalid token encountered", p.curToken)
@@ -100,6 +174,8 @@ func (p *Parser) parseBlockStatement() (Statement, error) {
 		Statements: make([]Statement, 0),
 	}
 
+	defer p.allowStructLiterals(true)()
+	defer p.allowArrowLambdas(true)()
 	p.read()
 	for !p.isCurToken(lexer.EOF) && !p.isCurToken(lexer.BRRIGHT) {
 		st, err := p.parseStatement()
@@ -129,11 +205,14 @@ func (p *Parser) parseIfExpression() (Expression, error) {
 	}
 
 	p.read()
-	if p.curToken.Token == lexer.BLEFT {
+	parenthesized := p.isCurToken(lexer.BLEFT)
+	if parenthesized {
 		p.read()
 	}
 
+	restore := p.allowStructLiterals(parenthesized)
 	ifExpr.Condition, err = p.parseExpression(LOWEST)
+	restore()
 	if err != nil {
 		return nil, err
 	}
@@ -163,6 +242,26 @@ func (p *Parser) parseIfExpression() (Expression, error) {
 	if p.peekToken.Token == lexer.ELSE {
 		p.read()
 		p.read()
+		if p.isCurToken(lexer.IF) {
+			// else if chain, nested if becomes the only statement of the alternative
+			elseIf, err := p.parseIfExpression()
+			if err != nil {
+				return nil, err
+			}
+
+			ifExpr.Alternative = &BlockStatement{
+				token: elseIf.Token(),
+				Statements: []Statement{
+					ExpressionStatement{
+						Expr: elseIf,
+						Tok:  elseIf.Token(),
+					},
+				},
+			}
+
+			return ifExpr, nil
+		}
+
 		alternative, err := p.parseBlockStatement()
 		if err != nil {
 			return nil, err
@@ -180,49 +279,197 @@ func (p *Parser) parseIfExpression() (Expression, error) {
 }
 
 func (p *Parser) ParseFuncExpression() (Expression, error) {
+	token := p.curToken
+	p.read()
+	fn, err := p.parseFunc(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return fn, nil
+}
+
+// parseFunc parses parameters, optional return type and the body of the function, current token is (
+func (p *Parser) parseFunc(token lexer.Token) (FuncExpression, error) {
 	fn := FuncExpression{
-		token: p.curToken,
+		token: token,
 	}
 
-	p.read()
 	if !p.isCurToken(lexer.BLEFT) {
-		return nil, NewParsingError("expected (", p.curToken)
+		return fn, NewParsingError("expected (", p.curToken)
 	}
 
 	// First element in Args
 	p.read()
-	if !p.isCurToken(lexer.BRIGHT) {
-		args, err := p.parseComaSeparatedExpressions()
+	args, err := p.parseParameters(lexer.BRIGHT)
+	if err != nil {
+		return fn, err
+	}
+
+	fn.Args = args
+	if p.peekToken.Token == lexer.ARROW {
+		p.read()
+		p.read()
+		fn.ReturnType, err = p.parseType()
 		if err != nil {
-			return nil, err
+			return fn, err
 		}
+	}
 
-		for _, arg := range args {
-			ident, ok := arg.(IdentifierExpression)
-			if !ok {
-				return nil, NewParsingError("expected Identifier", arg.Token())
-			}
-			fn.Args = append(fn.Args, ident)
+	p.read()
+	yields := p.yields
+	p.yields = false
+	body, err := p.parseBlockStatement()
+	fn.Generator = p.yields
+	p.yields = yields
+	if err != nil {
+		return fn, err
+	}
+	if body == nil {
+		return fn, NewParsingError("if Body is undefined", p.curToken)
+	}
+
+	fn.Body = body.(BlockStatement)
+	return fn, nil
+}
+
+// parseLambda parses |params| body and || body, current token is | or ||
+func (p *Parser) parseLambda() (Expression, error) {
+	fn := FuncExpression{
+		token: p.curToken,
+	}
+
+	p.read()
+	if fn.token.Token == lexer.BOR {
+		var err error
+		fn.Args, err = p.parseParameters(lexer.BOR)
+		if err != nil {
+			return nil, err
 		}
 
 		p.read()
 	}
 
-	if !p.isCurToken(lexer.BRIGHT) {
-		return nil, NewParsingError("expected )", p.curToken)
+	return p.parseLambdaBody(fn)
+}
+
+// parseArrowLambda parses name => body, current token is the name of the parameter
+func (p *Parser) parseArrowLambda() (Expression, error) {
+	fn := FuncExpression{
+		token: p.curToken,
+		Args:  []Parameter{{Pattern: NewIdentifier(p.curToken)}},
+	}
+
+	p.read()
+	p.read()
+	return p.parseLambdaBody(fn)
+}
+
+// parseLambdaBody parses the block or the single expression which is the body of the lambda, current token
+// is the first token of the body
+func (p *Parser) parseLambdaBody(fn FuncExpression) (Expression, error) {
+	yields := p.yields
+	p.yields = false
+	defer func() { p.yields = yields }()
+	if p.isCurToken(lexer.BRLEFT) {
+		body, err := p.parseBlockStatement()
+		if err != nil {
+			return nil, err
+		}
+
+		if body == nil {
+			return nil, NewParsingError("undefined body of lambda", fn.token)
+		}
+
+		fn.Body = body.(BlockStatement)
+		fn.Generator = p.yields
+		return fn, nil
+	}
+
+	token := p.curToken
+	expr, err := p.parseExpression(LOWEST)
+	if err != nil {
+		return nil, err
+	}
+
+	if expr == nil {
+		return nil, NewParsingError("expected body of lambda", token)
+	}
+
+	fn.Body = BlockStatement{
+		token:      token,
+		Statements: []Statement{ExpressionStatement{Expr: expr, Tok: token}},
+	}
+	fn.Generator = p.yields
+	return fn, nil
+}
+
+func (p *Parser) parseYieldExpression() (Expression, error) {
+	yield := YieldExpression{
+		token: p.curToken,
+	}
+
+	p.yields = true
+	p.read()
+	val, err := p.parseExpression(LOWEST)
+	if err != nil {
+		return nil, err
+	}
+
+	if val == nil {
+		return nil, NewParsingError("expected value to yield", yield.token)
+	}
+
+	yield.Val = val
+	return yield, nil
+}
+
+// parseForStatement parses for pattern in iterable { ... }
+func (p *Parser) parseForStatement() (Statement, error) {
+	stmt := ForStatement{
+		token: p.curToken,
+	}
+
+	p.read()
+	var err error
+	stmt.Pattern, err = p.parseBinding()
+	if err != nil {
+		return nil, err
+	}
+
+	p.read()
+	if !p.isCurToken(lexer.IN) {
+		return nil, NewParsingError("expected in", p.curToken)
+	}
+
+	p.read()
+	restore := p.allowStructLiterals(false)
+	stmt.Iterable, err = p.parseExpression(LOWEST)
+	restore()
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.Iterable == nil {
+		return nil, NewParsingError("expected iterable", p.curToken)
 	}
 
 	p.read()
+	if !p.isCurToken(lexer.BRLEFT) {
+		return nil, NewParsingError("expected {", p.curToken)
+	}
+
 	body, err := p.parseBlockStatement()
 	if err != nil {
 		return nil, err
 	}
+
 	if body == nil {
-		return nil, NewParsingError("if Body is undefined", p.curToken)
+		return nil, NewParsingError("undefined body of for", p.curToken)
 	}
 
-	fn.Body = body.(BlockStatement)
-	return fn, nil
+	stmt.Body = body.(BlockStatement)
+	return stmt, nil
 }
 
 func (p *Parser) parseComaSeparatedExpressions() ([]Expression, error) {
@@ -253,24 +500,75 @@ func (p *Parser) parseCallExpression(fn Expression) (Expression, error) {
 		Call:  fn,
 	}
 
+	defer p.allowStructLiterals(true)()
+	defer p.allowArrowLambdas(true)()
 	p.read()
-	if !p.isCurToken(lexer.BRIGHT) {
-		var err error
-		call.CallArgs, err = p.parseComaSeparatedExpressions()
+	for !p.isCurToken(lexer.BRIGHT) {
+		arg, err := p.parseCallArgument()
 		if err != nil {
 			return nil, err
 		}
 
+		_, named := arg.(NamedArgument)
+		if len(call.CallArgs) > 0 && !named {
+			if _, ok := call.CallArgs[len(call.CallArgs)-1].(NamedArgument); ok {
+				return nil, NewParsingError("positional argument after named argument", arg.Token())
+			}
+		}
+
+		call.CallArgs = append(call.CallArgs, arg)
 		p.read()
+		if p.isCurToken(lexer.COMA) {
+			p.read()
+		} else if !p.isCurToken(lexer.BRIGHT) {
+			return nil, NewParsingError("expected )", p.curToken)
+		}
 	}
 
 	return call, nil
 }
 
+// parseCallArgument parses positional argument, spread ...arr or named argument name: value
+func (p *Parser) parseCallArgument() (Expression, error) {
+	if p.isCurToken(lexer.ELLIPSIS) {
+		spread := SpreadExpression{
+			token: p.curToken,
+		}
+
+		p.read()
+		val, err := p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		spread.Val = val
+		return spread, nil
+	}
+
+	if p.isCurToken(lexer.IDENT) && p.peekToken.Token == lexer.COLON {
+		named := NamedArgument{
+			Name: NewIdentifier(p.curToken),
+		}
+
+		p.read()
+		p.read()
+		val, err := p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		named.Val = val
+		return named, nil
+	}
+
+	return p.parseExpression(LOWEST)
+}
+
 func (p *Parser) parseAssignExpression(ex Expression) (Expression, error) {
 	switch ex.(type) {
 	case IdentifierExpression:
 	case IndexExpression:
+	case MemberExpression:
 	default:
 		return nil, NewParsingError(fmt.Sprintf("expected Identifier, got %T\n", ex), p.curToken)
 	}
@@ -290,6 +588,10 @@ func (p *Parser) parseAssignExpression(ex Expression) (Expression, error) {
 	return assign, err
 }
 
+func (p *Parser) parseNilExpression() (Expression, error) {
+	return NilExpression{token: p.curToken}, nil
+}
+
 func (p *Parser) parseBoolExpression() (Expression, error) {
 	var val bool
 	if p.curToken.Token == lexer.TRUE {
@@ -332,93 +634,1056 @@ func (p *Parser) parseIndexExpression(expr Expression) (Expression, error) {
 		token: p.curToken,
 		Of:    expr,
 	}
+
+	defer p.allowStructLiterals(true)()
 	p.read()
+	if p.isCurToken(lexer.COLON) {
+		return p.parseSliceExpression(idx.token, expr, nil)
+	}
+
 	var err error
 	idx.Idx, err = p.parseExpression(LOWEST)
 	if err != nil {
 		return nil, err
 	}
 
+	if p.peekToken.Token == lexer.COLON {
+		p.read()
+		return p.parseSliceExpression(idx.token, expr, idx.Idx)
+	}
+
 	p.read()
+	if !p.isCurToken(lexer.SBRIGHT) {
+		return nil, NewParsingError("expected ]", p.curToken)
+	}
 
 	return idx, err
 }
 
-func (p *Parser) parseArrayExpression() (Expression, error) {
-	arr := ArrayExpression{
+// parseSliceExpression parses the end of a[start:end], current token is the colon
+func (p *Parser) parseMemberExpression(expr Expression) (Expression, error) {
+	member := MemberExpression{
 		token: p.curToken,
+		Of:    expr,
 	}
 
 	p.read()
-	if p.curToken.Token != lexer.SBRIGHT {
-		var err error
-		arr.Arr, err = p.parseComaSeparatedExpressions()
-		if err != nil {
-			return nil, err
-		}
-		p.read()
+	if !p.isCurToken(lexer.IDENT) {
+		return nil, NewParsingError("expected field name", p.curToken)
 	}
 
-	return arr, nil
+	member.Field = NewIdentifier(p.curToken)
+	return member, nil
 }
 
-func (p *Parser) parseHashMap() (Expression, error) {
-	mp := HashMapExpression{
+// parsePipeExpression parses the right side of x |> f(a), current token is the pipe
+func (p *Parser) parsePipeExpression(expr Expression) (Expression, error) {
+	pipe := PipeExpression{
 		token: p.curToken,
+		Left:  expr,
 	}
 
 	p.read()
-	if p.curToken.Token != lexer.BRRIGHT {
-		var err error
-		mp.Map, err = p.parseComaSeparatedPairs()
-		if err != nil {
-			return nil, err
-		}
-		p.read()
+	if p.isCurToken(lexer.EOF) || p.isCurToken(lexer.SCOLON) {
+		return nil, NewParsingError("expected function after |>", p.curToken)
 	}
 
-	return mp, nil
+	var err error
+	pipe.Right, err = p.parseExpression(PIPE)
+	return pipe, err
 }
 
-func (p *Parser) parseComaSeparatedPairs() (map[Expression]Expression, error) {
-	expressions := make(map[Expression]Expression)
-	key, val, err := p.parserPair()
-	if err != nil {
-		return nil, err
+// parseStructExpression parses Name{field: value, ...}, current token is the name of the struct
+func (p *Parser) parseStructExpression() (Expression, error) {
+	st := StructExpression{
+		Name: NewIdentifier(p.curToken),
 	}
 
-	expressions[key] = val
-	for p.peekToken.Token == lexer.COMA {
+	defer p.allowStructLiterals(true)()
+	p.read()
+	p.read()
+	for !p.isCurToken(lexer.BRRIGHT) {
+		if !p.isCurToken(lexer.IDENT) || p.peekToken.Token != lexer.COLON {
+			return nil, NewParsingError("expected field: value", p.curToken)
+		}
+
+		field := NamedArgument{
+			Name: NewIdentifier(p.curToken),
+		}
+
 		p.read()
 		p.read()
-		key, val, err = p.parserPair()
+		val, err := p.parseExpression(LOWEST)
 		if err != nil {
 			return nil, err
 		}
 
-		expressions[key] = val
+		field.Val = val
+		st.Fields = append(st.Fields, field)
+		p.read()
+		if p.isCurToken(lexer.COMA) {
+			p.read()
+		} else if !p.isCurToken(lexer.BRRIGHT) {
+			return nil, NewParsingError("expected }", p.curToken)
+		}
 	}
 
-	return expressions, nil
+	return st, nil
 }
 
-func (p *Parser) parserPair() (Expression, Expression, error) {
-	key, err := p.parseExpression(LOWEST)
-	if err != nil {
-		return nil, nil, err
+// parseStructStatement parses struct Name { field: type, ... }, types of the fields are optional
+func (p *Parser) parseStructStatement() (Statement, error) {
+	st := StructStatement{
+		token: p.curToken,
 	}
 
-	if p.peekToken.Token != lexer.COLON {
-		return nil, nil, NewParsingError("expected proceeding column", p.curToken)
+	p.read()
+	if !p.isCurToken(lexer.IDENT) {
+		return nil, NewParsingError("expected struct name", p.curToken)
 	}
 
+	if !isTypeName(p.curToken.Literal) {
+		return nil, NewParsingError("struct name must start with an upper case letter", p.curToken)
+	}
+
+	st.Name = NewIdentifier(p.curToken)
 	p.read()
+	if !p.isCurToken(lexer.BRLEFT) {
+		return nil, NewParsingError("expected {", p.curToken)
+	}
+
 	p.read()
+	defined := make(map[string]bool)
+	for !p.isCurToken(lexer.BRRIGHT) {
+		if p.isCurToken(lexer.FUNC) {
+			method, err := p.parseMethod()
+			if err != nil {
+				return nil, err
+			}
 
-	val, err := p.parseExpression(LOWEST)
-	if err != nil {
-		return nil, nil, err
+			if defined[method.Name.Identifier.Literal] {
+				return nil, NewParsingError("method is already defined", method.Name.Token())
+			}
+
+			defined[method.Name.Identifier.Literal] = true
+			st.Methods = append(st.Methods, method)
+			// methods end with }, so the comma after them is optional
+			p.read()
+			if p.isCurToken(lexer.COMA) {
+				p.read()
+			}
+
+			continue
+		}
+
+		if !p.isCurToken(lexer.IDENT) {
+			return nil, NewParsingError("expected field name", p.curToken)
+		}
+
+		if defined[p.curToken.Literal] {
+			return nil, NewParsingError("field is already defined", p.curToken)
+		}
+
+		defined[p.curToken.Literal] = true
+		field := Field{
+			Name: NewIdentifier(p.curToken),
+		}
+
+		var err error
+		field.Type, err = p.parseOptionalType()
+		if err != nil {
+			return nil, err
+		}
+
+		st.Fields = append(st.Fields, field)
+		p.read()
+		if p.isCurToken(lexer.COMA) {
+			p.read()
+		} else if !p.isCurToken(lexer.BRRIGHT) {
+			return nil, NewParsingError("expected }", p.curToken)
+		}
 	}
 
-	return key, val, err
+	return st, nil
+}
+
+// parseMethod parses fn name(receiver, params) { body }, the receiver is the first parameter
+func (p *Parser) parseMethod() (Method, error) {
+	token := p.curToken
+	p.read()
+	if !p.isCurToken(lexer.IDENT) {
+		return Method{}, NewParsingError("expected method name", p.curToken)
+	}
+
+	method := Method{
+		Name: NewIdentifier(p.curToken),
+	}
+
+	p.read()
+	fn, err := p.parseFunc(token)
+	if err != nil {
+		return method, err
+	}
+
+	if len(fn.Args) == 0 || fn.Args[0].Rest {
+		return method, NewParsingError("method must accept the receiver as the first parameter", method.Name.Token())
+	}
+
+	method.Func = fn
+	return method, nil
+}
+
+// parseEnumStatement parses enum Name { Variant, Variant(field: type, ...), ... }
+func (p *Parser) parseEnumStatement() (Statement, error) {
+	enum := EnumStatement{
+		token: p.curToken,
+	}
+
+	p.read()
+	if !p.isCurToken(lexer.IDENT) || !isTypeName(p.curToken.Literal) {
+		return nil, NewParsingError("enum name must start with an upper case letter", p.curToken)
+	}
+
+	enum.Name = NewIdentifier(p.curToken)
+	p.read()
+	if !p.isCurToken(lexer.BRLEFT) {
+		return nil, NewParsingError("expected {", p.curToken)
+	}
+
+	p.read()
+	defined := make(map[string]bool)
+	for !p.isCurToken(lexer.BRRIGHT) {
+		if !p.isCurToken(lexer.IDENT) || !isTypeName(p.curToken.Literal) {
+			return nil, NewParsingError("variant name must start with an upper case letter", p.curToken)
+		}
+
+		if defined[p.curToken.Literal] {
+			return nil, NewParsingError("variant is already defined", p.curToken)
+		}
+
+		defined[p.curToken.Literal] = true
+		variant := Variant{
+			Name: NewIdentifier(p.curToken),
+		}
+
+		if p.peekToken.Token == lexer.BLEFT {
+			p.read()
+			fields, err := p.parseVariantFields()
+			if err != nil {
+				return nil, err
+			}
+
+			variant.Fields = fields
+		}
+
+		enum.Variants = append(enum.Variants, variant)
+		p.read()
+		if p.isCurToken(lexer.COMA) {
+			p.read()
+		} else if !p.isCurToken(lexer.BRRIGHT) {
+			return nil, NewParsingError("expected }", p.curToken)
+		}
+	}
+
+	return enum, nil
+}
+
+// parseVariantFields parses (field: type, ...), current token is (
+func (p *Parser) parseVariantFields() ([]Field, error) {
+	var fields []Field
+	defined := make(map[string]bool)
+	p.read()
+	for !p.isCurToken(lexer.BRIGHT) {
+		if !p.isCurToken(lexer.IDENT) {
+			return nil, NewParsingError("expected field name", p.curToken)
+		}
+
+		if defined[p.curToken.Literal] {
+			return nil, NewParsingError("field is already defined", p.curToken)
+		}
+
+		defined[p.curToken.Literal] = true
+		field := Field{
+			Name: NewIdentifier(p.curToken),
+		}
+
+		var err error
+		field.Type, err = p.parseOptionalType()
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, field)
+		p.read()
+		if p.isCurToken(lexer.COMA) {
+			p.read()
+		} else if !p.isCurToken(lexer.BRIGHT) {
+			return nil, NewParsingError("expected )", p.curToken)
+		}
+	}
+
+	return fields, nil
+}
+
+func (p *Parser) parseSliceExpression(token lexer.Token, expr, start Expression) (Expression, error) {
+	slice := SliceExpression{
+		token: token,
+		Of:    expr,
+		Start: start,
+	}
+
+	p.read()
+	if !p.isCurToken(lexer.SBRIGHT) {
+		var err error
+		slice.End, err = p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		p.read()
+	}
+
+	if !p.isCurToken(lexer.SBRIGHT) {
+		return nil, NewParsingError("expected ]", p.curToken)
+	}
+
+	return slice, nil
+}
+
+func (p *Parser) parseArrayExpression() (Expression, error) {
+	arr := ArrayExpression{
+		token: p.curToken,
+	}
+
+	defer p.allowStructLiterals(true)()
+	p.read()
+	if p.curToken.Token != lexer.SBRIGHT {
+		var err error
+		arr.Arr, err = p.parseComaSeparatedExpressions()
+		if err != nil {
+			return nil, err
+		}
+		p.read()
+	}
+
+	return arr, nil
+}
+
+func (p *Parser) parseHashMap() (Expression, error) {
+	mp := HashMapExpression{
+		token: p.curToken,
+	}
+
+	defer p.allowStructLiterals(true)()
+	p.read()
+	if p.curToken.Token != lexer.BRRIGHT {
+		var err error
+		mp.Map, err = p.parseComaSeparatedPairs()
+		if err != nil {
+			return nil, err
+		}
+		p.read()
+	}
+
+	return mp, nil
+}
+
+func (p *Parser) parseComaSeparatedPairs() (map[Expression]Expression, error) {
+	expressions := make(map[Expression]Expression)
+	key, val, err := p.parserPair()
+	if err != nil {
+		return nil, err
+	}
+
+	expressions[key] = val
+	for p.peekToken.Token == lexer.COMA {
+		p.read()
+		p.read()
+		key, val, err = p.parserPair()
+		if err != nil {
+			return nil, err
+		}
+
+		expressions[key] = val
+	}
+
+	return expressions, nil
+}
+
+func (p *Parser) parserPair() (Expression, Expression, error) {
+	key, err := p.parseExpression(LOWEST)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if p.peekToken.Token != lexer.COLON {
+		return nil, nil, NewParsingError("expected proceeding column", p.curToken)
+	}
+
+	p.read()
+	p.read()
+
+	val, err := p.parseExpression(LOWEST)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, val, err
+}
+
+func (p *Parser) parseMatchExpression() (Expression, error) {
+	match := MatchExpression{
+		token: p.curToken,
+	}
+
+	p.read()
+	var err error
+	restore := p.allowStructLiterals(false)
+	match.Value, err = p.parseExpression(LOWEST)
+	restore()
+	if err != nil {
+		return nil, err
+	}
+
+	p.read()
+	if !p.isCurToken(lexer.BRLEFT) {
+		return nil, NewParsingError("expected {", p.curToken)
+	}
+
+	p.read()
+	for !p.isCurToken(lexer.BRRIGHT) && !p.isCurToken(lexer.EOF) {
+		arm, err := p.parseMatchArm()
+		if err != nil {
+			return nil, err
+		}
+
+		match.Arms = append(match.Arms, arm)
+		p.read()
+		if p.isCurToken(lexer.COMA) {
+			p.read()
+		}
+	}
+
+	if !p.isCurToken(lexer.BRRIGHT) {
+		return nil, NewParsingError("expected closing bracket, got EOF", match.token)
+	}
+
+	return match, nil
+}
+
+// parseMatchArm parses pattern [if guard] => expression or block, current token is the last token of the arm
+func (p *Parser) parseMatchArm() (MatchArm, error) {
+	var (
+		arm MatchArm
+		err error
+	)
+
+	arm.Pattern, err = p.parsePattern()
+	if err != nil {
+		return arm, err
+	}
+
+	if p.peekToken.Token == lexer.IF {
+		p.read()
+		p.read()
+		restore := p.allowArrowLambdas(false)
+		arm.Guard, err = p.parseExpression(LOWEST)
+		restore()
+		if err != nil {
+			return arm, err
+		}
+	}
+
+	arm.Body, err = p.parseArmBody()
+	return arm, err
+}
+
+// parseArmBody parses => expression or block of the match and select arms, current token is the last token
+// before =>
+func (p *Parser) parseArmBody() (BlockStatement, error) {
+	p.read()
+	if !p.isCurToken(lexer.FATARROW) {
+		return BlockStatement{}, NewParsingError("expected =>", p.curToken)
+	}
+
+	p.read()
+	if p.isCurToken(lexer.BRLEFT) {
+		body, err := p.parseBlockStatement()
+		if err != nil {
+			return BlockStatement{}, err
+		}
+
+		if body == nil {
+			return BlockStatement{}, NewParsingError("undefined body of arm", p.curToken)
+		}
+
+		return body.(BlockStatement), nil
+	}
+
+	tok := p.curToken
+	expr, err := p.parseExpression(LOWEST)
+	if err != nil {
+		return BlockStatement{}, err
+	}
+
+	return BlockStatement{
+		token: tok,
+		Statements: []Statement{
+			ExpressionStatement{
+				Expr: expr,
+				Tok:  tok,
+			},
+		},
+	}, nil
+}
+
+// parseSelectExpression parses select { arm, ... }, current token is select
+func (p *Parser) parseSelectExpression() (Expression, error) {
+	sel := SelectExpression{
+		token: p.curToken,
+	}
+
+	p.read()
+	if !p.isCurToken(lexer.BRLEFT) {
+		return nil, NewParsingError("expected {", p.curToken)
+	}
+
+	p.read()
+	for !p.isCurToken(lexer.BRRIGHT) && !p.isCurToken(lexer.EOF) {
+		arm, err := p.parseSelectArm()
+		if err != nil {
+			return nil, err
+		}
+
+		sel.Arms = append(sel.Arms, arm)
+		p.read()
+		if p.isCurToken(lexer.COMA) {
+			p.read()
+		}
+	}
+
+	if !p.isCurToken(lexer.BRRIGHT) {
+		return nil, NewParsingError("expected closing bracket, got EOF", sel.token)
+	}
+
+	defaults := 0
+	for _, arm := range sel.Arms {
+		if arm.Default() {
+			defaults++
+		}
+	}
+
+	if defaults > 1 {
+		return nil, NewParsingError("select has more than one default arm", sel.token)
+	}
+
+	return sel, nil
+}
+
+// parseSelectArm parses pattern in channel => body, channel.send(value) => body or _ => body, current token
+// is the last token of the arm
+func (p *Parser) parseSelectArm() (SelectArm, error) {
+	var (
+		arm SelectArm
+		err error
+	)
+
+	// name => would start the lambda
+	restore := p.allowArrowLambdas(false)
+	defer restore()
+	switch {
+	case p.isCurToken(lexer.IDENT) && p.curToken.Literal == "_" && p.peekToken.Token == lexer.FATARROW:
+	case p.isCurToken(lexer.IDENT) && !isTypeName(p.curToken.Literal) && p.peekToken.Token != lexer.IN:
+		expr, err := p.parseExpression(LOWEST)
+		if err != nil {
+			return arm, err
+		}
+
+		call, ok := expr.(CallExpression)
+		member, isMember := call.Call.(MemberExpression)
+		if !ok || !isMember || member.Field.Identifier.Literal != "send" || len(call.CallArgs) != 1 {
+			return arm, NewParsingError("expected pattern in channel or channel.send(value)", expr.Token())
+		}
+
+		switch call.CallArgs[0].(type) {
+		case SpreadExpression, NamedArgument:
+			return arm, NewParsingError("expected value to send", call.CallArgs[0].Token())
+		}
+
+		arm.Channel, arm.Send = member.Of, call.CallArgs[0]
+	default:
+		arm.Pattern, err = p.parsePattern()
+		if err != nil {
+			return arm, err
+		}
+
+		p.read()
+		if !p.isCurToken(lexer.IN) {
+			return arm, NewParsingError("expected in", p.curToken)
+		}
+
+		p.read()
+		arm.Channel, err = p.parseExpression(LOWEST)
+		if err != nil {
+			return arm, err
+		}
+	}
+
+	restore()
+	arm.Body, err = p.parseArmBody()
+	return arm, err
+}
+
+// parseSpawnExpression parses spawn f(args), current token is spawn
+func (p *Parser) parseSpawnExpression() (Expression, error) {
+	spawn := SpawnExpression{
+		token: p.curToken,
+	}
+
+	p.read()
+	expr, err := p.parseExpression(PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	call, ok := expr.(CallExpression)
+	if !ok {
+		return nil, NewParsingError("expected call after spawn", spawn.token)
+	}
+
+	spawn.Call = call
+	return spawn, nil
+}
+
+// parseAwaitExpression parses await task, current token is await
+func (p *Parser) parseAwaitExpression() (Expression, error) {
+	await := AwaitExpression{
+		token: p.curToken,
+	}
+
+	p.read()
+	var err error
+	await.Task, err = p.parseExpression(PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	return await, nil
+}
+
+// parsePattern parses alternatives separated by |, current token is the last token of the pattern
+func (p *Parser) parsePattern() (Pattern, error) {
+	pattern, err := p.parsePatternElement()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peekToken.Token != lexer.BOR {
+		return pattern, nil
+	}
+
+	alternative := AlternativePattern{
+		Alternatives: []Pattern{pattern},
+	}
+
+	for p.peekToken.Token == lexer.BOR {
+		p.read()
+		p.read()
+		pattern, err = p.parsePatternElement()
+		if err != nil {
+			return nil, err
+		}
+
+		if !sameIdentifiers(PatternIdentifiers(alternative.Alternatives[0]), PatternIdentifiers(pattern)) {
+			return nil, NewParsingError("all alternatives must bind the same identifiers", pattern.Token())
+		}
+
+		alternative.Alternatives = append(alternative.Alternatives, pattern)
+	}
+
+	return alternative, nil
+}
+
+func sameIdentifiers(a, b []IdentifierExpression) bool {
+	names := make(map[string]int)
+	for _, ident := range a {
+		names[ident.Identifier.Literal]++
+	}
+
+	for _, ident := range b {
+		names[ident.Identifier.Literal]--
+	}
+
+	for _, count := range names {
+		if count != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// closingLiteral returns the token closing parameters, ) of functions or | of lambdas
+func closingLiteral(end lexer.TokenType) string {
+	if end == lexer.BOR {
+		return "|"
+	}
+
+	return ")"
+}
+
+// parseParameters parses function parameters up to the closing ) or |, parameters with default values must follow
+// the required ones and the rest parameter must be the last one
+func (p *Parser) parseParameters(end lexer.TokenType) ([]Parameter, error) {
+	// types and default values of lambda parameters end before |
+	parseType, defaultPrecedence := p.parseOptionalType, LOWEST
+	if end == lexer.BOR {
+		parseType, defaultPrecedence = p.parseOptionalTypeElement, BIN_OR
+	}
+
+	var params []Parameter
+	for !p.isCurToken(end) {
+		if len(params) > 0 && params[len(params)-1].Rest {
+			return nil, NewParsingError("rest parameter must be the last one", p.curToken)
+		}
+
+		var (
+			param Parameter
+			err   error
+		)
+		if p.isCurToken(lexer.ELLIPSIS) {
+			p.read()
+			if !p.isCurToken(lexer.IDENT) {
+				return nil, NewParsingError("expected Identifier", p.curToken)
+			}
+
+			param = Parameter{Pattern: NewIdentifier(p.curToken), Rest: true}
+			param.Type, err = parseType()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			pattern, err := p.parseBinding()
+			if err != nil {
+				return nil, err
+			}
+
+			param.Pattern = pattern
+			param.Type, err = parseType()
+			if err != nil {
+				return nil, err
+			}
+
+			if p.peekToken.Token == lexer.ASSIGN {
+				p.read()
+				p.read()
+				param.Default, err = p.parseExpression(defaultPrecedence)
+				if err != nil {
+					return nil, err
+				}
+			} else if len(params) > 0 && params[len(params)-1].Default != nil {
+				return nil, NewParsingError("parameter without default value must precede parameters with default values", p.curToken)
+			}
+		}
+
+		params = append(params, param)
+		p.read()
+		if p.isCurToken(lexer.COMA) {
+			p.read()
+		} else if !p.isCurToken(end) {
+			return nil, NewParsingError(fmt.Sprintf("expected %s", closingLiteral(end)), p.curToken)
+		}
+	}
+
+	return params, nil
+}
+
+// parseBinding parses the pattern of let and parameters, a single name is always bound, so constants
+// can be named in upper case: const MAX = 10
+func (p *Parser) parseBinding() (Pattern, error) {
+	if p.isCurToken(lexer.IDENT) && p.curToken.Literal != "_" && p.peekToken.Token != lexer.BLEFT {
+		return NewIdentifier(p.curToken), nil
+	}
+
+	return p.parsePatternElement()
+}
+
+func (p *Parser) parsePatternElement() (Pattern, error) {
+	switch {
+	case p.isCurToken(lexer.IDENT) && p.curToken.Literal == "_":
+		return WildcardPattern{token: p.curToken}, nil
+	case p.isCurToken(lexer.IDENT) && isTypeName(p.curToken.Literal):
+		return p.parseVariantPattern()
+	case p.isCurToken(lexer.IDENT):
+		return NewIdentifier(p.curToken), nil
+	case p.isCurToken(lexer.SBLEFT):
+		return p.parseArrayPattern()
+	case p.isCurToken(lexer.BRLEFT):
+		return p.parseMapPattern()
+	}
+
+	low, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peekToken.Token != lexer.DOTDOT && p.peekToken.Token != lexer.DOTDOTEQ {
+		return LiteralPattern{Val: low}, nil
+	}
+
+	p.read()
+	rng := RangePattern{
+		token:     p.curToken,
+		Low:       low,
+		Inclusive: p.isCurToken(lexer.DOTDOTEQ),
+	}
+
+	p.read()
+	rng.High, err = p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	return rng, nil
+}
+
+func (p *Parser) parseVariantPattern() (Pattern, error) {
+	variant := VariantPattern{
+		Name: NewIdentifier(p.curToken),
+	}
+
+	if p.peekToken.Token != lexer.BLEFT {
+		return variant, nil
+	}
+
+	p.read()
+	p.read()
+	variant.Fields = make([]Pattern, 0)
+	for !p.isCurToken(lexer.BRIGHT) {
+		field, err := p.parsePattern()
+		if err != nil {
+			return nil, err
+		}
+
+		variant.Fields = append(variant.Fields, field)
+		p.read()
+		if p.isCurToken(lexer.COMA) {
+			p.read()
+		} else if !p.isCurToken(lexer.BRIGHT) {
+			return nil, NewParsingError("expected )", p.curToken)
+		}
+	}
+
+	return variant, nil
+}
+
+func (p *Parser) parseArrayPattern() (Pattern, error) {
+	arr := ArrayPattern{
+		token: p.curToken,
+	}
+
+	p.read()
+	for !p.isCurToken(lexer.SBRIGHT) {
+		if arr.Rest != nil {
+			return nil, NewParsingError("rest element must be the last one", p.curToken)
+		}
+
+		if p.isCurToken(lexer.ELLIPSIS) {
+			p.read()
+			if !p.isCurToken(lexer.IDENT) {
+				return nil, NewParsingError("expected Identifier", p.curToken)
+			}
+
+			rest := NewIdentifier(p.curToken)
+			arr.Rest = &rest
+		} else {
+			element, err := p.parsePattern()
+			if err != nil {
+				return nil, err
+			}
+
+			arr.Elements = append(arr.Elements, element)
+		}
+
+		p.read()
+		if p.isCurToken(lexer.COMA) {
+			p.read()
+		} else if !p.isCurToken(lexer.SBRIGHT) {
+			return nil, NewParsingError("expected ]", p.curToken)
+		}
+	}
+
+	return arr, nil
+}
+
+func (p *Parser) parseMapPattern() (Pattern, error) {
+	mp := MapPattern{
+		token: p.curToken,
+	}
+
+	p.read()
+	for !p.isCurToken(lexer.BRRIGHT) {
+		var entry MapPatternEntry
+		switch p.curToken.Token {
+		case lexer.IDENT:
+			entry.Key = StringExpression{tok: p.curToken, Val: p.curToken.Literal}
+			entry.Pattern = NewIdentifier(p.curToken)
+		case lexer.STRING, lexer.NUMBER, lexer.HYPHEN, lexer.TRUE, lexer.FALSE:
+			key, err := p.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+
+			entry.Key = key
+			if p.peekToken.Token != lexer.COLON {
+				return nil, NewParsingError("expected :", p.peekToken)
+			}
+		default:
+			return nil, NewParsingError("expected map key", p.curToken)
+		}
+
+		if p.peekToken.Token == lexer.COLON {
+			p.read()
+			p.read()
+			var err error
+			entry.Pattern, err = p.parsePattern()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		mp.Entries = append(mp.Entries, entry)
+		p.read()
+		if p.isCurToken(lexer.COMA) {
+			p.read()
+		} else if !p.isCurToken(lexer.BRRIGHT) {
+			return nil, NewParsingError("expected }", p.curToken)
+		}
+	}
+
+	return mp, nil
+}
+
+// parseLiteral parses number, negative number, string or bool literal
+func (p *Parser) parseLiteral() (Expression, error) {
+	switch p.curToken.Token {
+	case lexer.NUMBER:
+		return p.ParseNumber()
+	case lexer.FLOAT:
+		return p.ParseFloat()
+	case lexer.STRING:
+		return p.parseStringExpression()
+	case lexer.TRUE, lexer.FALSE:
+		return p.parseBoolExpression()
+	case lexer.HYPHEN:
+		if p.peekToken.Token != lexer.NUMBER && p.peekToken.Token != lexer.FLOAT {
+			return nil, NewParsingError("expected number", p.peekToken)
+		}
+
+		prefix := PrefixExpression{
+			Prefix: p.curToken,
+		}
+
+		p.read()
+		var err error
+		prefix.Expr, err = p.parseLiteral()
+		return prefix, err
+	}
+
+	return nil, NewParsingError("expected literal", p.curToken)
+}
+
+// parseOptionalType parses type annotation following :, returns nil if the next token is not :
+func (p *Parser) parseOptionalType() (TypeAnnotation, error) {
+	if p.peekToken.Token != lexer.COLON {
+		return nil, nil
+	}
+
+	p.read()
+	p.read()
+	return p.parseType()
+}
+
+// parseOptionalTypeElement parses the annotation of the lambda parameter, which can not be the union as |
+// closes the parameters: |x: int| x
+func (p *Parser) parseOptionalTypeElement() (TypeAnnotation, error) {
+	if p.peekToken.Token != lexer.COLON {
+		return nil, nil
+	}
+
+	p.read()
+	p.read()
+	return p.parseTypeElement()
+}
+
+// parseType parses type annotation, types separated by | form the union type
+func (p *Parser) parseType() (TypeAnnotation, error) {
+	t, err := p.parseTypeElement()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peekToken.Token != lexer.BOR {
+		return t, nil
+	}
+
+	union := UnionType{Types: []TypeAnnotation{t}}
+	for p.peekToken.Token == lexer.BOR {
+		p.read()
+		p.read()
+		t, err = p.parseTypeElement()
+		if err != nil {
+			return nil, err
+		}
+
+		union.Types = append(union.Types, t)
+	}
+
+	return union, nil
+}
+
+func (p *Parser) parseTypeElement() (TypeAnnotation, error) {
+	switch p.curToken.Token {
+	case lexer.IDENT, lexer.NIL, lexer.FUNC:
+	default:
+		return nil, NewParsingError("expected type", p.curToken)
+	}
+
+	if p.peekToken.Token != lexer.LT {
+		return NamedType{token: p.curToken, Name: p.curToken.Literal}, nil
+	}
+
+	generic := GenericType{
+		token: p.curToken,
+		Name:  p.curToken.Literal,
+	}
+
+	p.read()
+	for {
+		p.read()
+		param, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		generic.Params = append(generic.Params, param)
+		if p.peekToken.Token != lexer.COMA {
+			break
+		}
+
+		p.read()
+	}
+
+	switch p.peekToken.Token {
+	case lexer.GT:
+		p.read()
+	case lexer.BRSHIFT:
+		// >> closes two nested types, map<string, array<int>>, the second > is left for the outer type
+		p.peekToken.Token, p.peekToken.Literal = lexer.GT, ">"
+		p.curToken = p.peekToken
+	default:
+		return nil, NewParsingError("expected >", p.peekToken)
+	}
+
+	return generic, nil
 }
@@ -0,0 +1,360 @@
+package parser
+
+import (
+	"bytes"
+	"github.com/charkpep/yami/src/lexer"
+	"strings"
+)
+
+// Pattern checks the shape of the value and binds its parts to identifiers, used by match arms,
+// let statements and function parameters
+type Pattern interface {
+	Node
+	pattern()
+}
+
+// PatternIdentifiers returns identifiers bound by the pattern in order of their appearance
+func PatternIdentifiers(pattern Pattern) []IdentifierExpression {
+	switch p := pattern.(type) {
+	case IdentifierExpression:
+		return []IdentifierExpression{p}
+	case ArrayPattern:
+		var identifiers []IdentifierExpression
+		for _, element := range p.Elements {
+			identifiers = append(identifiers, PatternIdentifiers(element)...)
+		}
+
+		if p.Rest != nil {
+			identifiers = append(identifiers, *p.Rest)
+		}
+
+		return identifiers
+	case MapPattern:
+		var identifiers []IdentifierExpression
+		for _, entry := range p.Entries {
+			identifiers = append(identifiers, PatternIdentifiers(entry.Pattern)...)
+		}
+
+		return identifiers
+	case AlternativePattern:
+		// every alternative binds the same identifiers
+		return PatternIdentifiers(p.Alternatives[0])
+	case VariantPattern:
+		var identifiers []IdentifierExpression
+		for _, field := range p.Fields {
+			identifiers = append(identifiers, PatternIdentifiers(field)...)
+		}
+
+		return identifiers
+	}
+
+	return nil
+}
+
+// PatternBindings returns every identifier node of the pattern including identifiers of all alternatives,
+// the same name may appear several times
+func PatternBindings(pattern Pattern) []IdentifierExpression {
+	switch p := pattern.(type) {
+	case ArrayPattern:
+		var identifiers []IdentifierExpression
+		for _, element := range p.Elements {
+			identifiers = append(identifiers, PatternBindings(element)...)
+		}
+
+		if p.Rest != nil {
+			identifiers = append(identifiers, *p.Rest)
+		}
+
+		return identifiers
+	case MapPattern:
+		var identifiers []IdentifierExpression
+		for _, entry := range p.Entries {
+			identifiers = append(identifiers, PatternBindings(entry.Pattern)...)
+		}
+
+		return identifiers
+	case AlternativePattern:
+		var identifiers []IdentifierExpression
+		for _, alternative := range p.Alternatives {
+			identifiers = append(identifiers, PatternBindings(alternative)...)
+		}
+
+		return identifiers
+	case VariantPattern:
+		var identifiers []IdentifierExpression
+		for _, field := range p.Fields {
+			identifiers = append(identifiers, PatternBindings(field)...)
+		}
+
+		return identifiers
+	}
+
+	return PatternIdentifiers(pattern)
+}
+
+// WildcardPattern _ matches any value
+type WildcardPattern struct {
+	token lexer.Token
+}
+
+func (w WildcardPattern) Token() lexer.Token {
+	return w.token
+}
+
+func (w WildcardPattern) pattern() {}
+
+func (w WildcardPattern) String() string {
+	return "_"
+}
+
+// LiteralPattern matches values equal to the literal: 1, -1, 1.5, "a", true
+type LiteralPattern struct {
+	Val Expression
+}
+
+func (l LiteralPattern) Token() lexer.Token {
+	return l.Val.Token()
+}
+
+func (l LiteralPattern) pattern() {}
+
+func (l LiteralPattern) String() string {
+	return l.Val.String()
+}
+
+// RangePattern low..high or low..=high matches numbers within the range
+type RangePattern struct {
+	token     lexer.Token
+	Low       Expression
+	High      Expression
+	Inclusive bool
+}
+
+func (r RangePattern) Token() lexer.Token {
+	return r.token
+}
+
+func (r RangePattern) pattern() {}
+
+func (r RangePattern) String() string {
+	if r.Inclusive {
+		return r.Low.String() + "..=" + r.High.String()
+	}
+
+	return r.Low.String() + ".." + r.High.String()
+}
+
+// AlternativePattern "a" | "b" matches if any of the alternatives matches
+type AlternativePattern struct {
+	Alternatives []Pattern
+}
+
+func (a AlternativePattern) Token() lexer.Token {
+	return a.Alternatives[0].Token()
+}
+
+func (a AlternativePattern) pattern() {}
+
+func (a AlternativePattern) String() string {
+	alternatives := make([]string, 0, len(a.Alternatives))
+	for _, alternative := range a.Alternatives {
+		alternatives = append(alternatives, alternative.String())
+	}
+
+	return strings.Join(alternatives, " | ")
+}
+
+// ArrayPattern [a, b, ...rest] matches arrays, without the rest element length of the array must be equal
+// to the number of elements
+type ArrayPattern struct {
+	token    lexer.Token
+	Elements []Pattern
+	Rest     *IdentifierExpression
+}
+
+func (a ArrayPattern) Token() lexer.Token {
+	return a.token
+}
+
+func (a ArrayPattern) pattern() {}
+
+func (a ArrayPattern) String() string {
+	elements := make([]string, 0, len(a.Elements)+1)
+	for _, element := range a.Elements {
+		elements = append(elements, element.String())
+	}
+
+	if a.Rest != nil {
+		elements = append(elements, "..."+a.Rest.String())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// MapPatternEntry key: pattern, shorthand {name} is the same as {name: name}
+type MapPatternEntry struct {
+	Key     Expression
+	Pattern Pattern
+}
+
+// MapPattern {name, age: years} matches maps containing all the keys, identifier keys are string keys
+type MapPattern struct {
+	token   lexer.Token
+	Entries []MapPatternEntry
+}
+
+func (m MapPattern) Token() lexer.Token {
+	return m.token
+}
+
+func (m MapPattern) pattern() {}
+
+func (m MapPattern) String() string {
+	entries := make([]string, 0, len(m.Entries))
+	for _, entry := range m.Entries {
+		key, isString := entry.Key.(StringExpression)
+		if ident, ok := entry.Pattern.(IdentifierExpression); ok && isString && ident.String() == key.Val {
+			entries = append(entries, ident.String())
+			continue
+		}
+
+		entries = append(entries, entry.Key.String()+": "+entry.Pattern.String())
+	}
+
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// VariantPattern Ok(value) matches values of the enum variant and destructures its fields, None matches
+// the variant without fields. Names starting with the upper case letter are variants, not bindings.
+type VariantPattern struct {
+	Name   IdentifierExpression
+	Fields []Pattern
+}
+
+func (v VariantPattern) Token() lexer.Token {
+	return v.Name.Token()
+}
+
+func (v VariantPattern) pattern() {}
+
+func (v VariantPattern) String() string {
+	if v.Fields == nil {
+		return v.Name.String()
+	}
+
+	fields := make([]string, 0, len(v.Fields))
+	for _, field := range v.Fields {
+		fields = append(fields, field.String())
+	}
+
+	return v.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression
+	Body    BlockStatement
+}
+
+func (m MatchArm) String() string {
+	var buff bytes.Buffer
+	buff.WriteString(m.Pattern.String())
+	if m.Guard != nil {
+		buff.WriteString(" if ")
+		buff.WriteString(m.Guard.String())
+	}
+
+	buff.WriteString(" => ")
+	buff.WriteString(m.Body.String())
+	return buff.String()
+}
+
+// SelectArm arm of select: pattern in channel => body receives from the channel, channel.send(value) => body
+// sends to it and _ => body is evaluated if no other arm can proceed
+type SelectArm struct {
+	// Pattern binds the received value, nil for sending arms
+	Pattern Pattern
+	// Channel is nil for the default arm
+	Channel Expression
+	// Send value to send, nil for receiving arms
+	Send Expression
+	Body BlockStatement
+}
+
+// Default reports whether the arm is the default one
+func (s SelectArm) Default() bool {
+	return s.Channel == nil
+}
+
+func (s SelectArm) String() string {
+	var buff bytes.Buffer
+	switch {
+	case s.Default():
+		buff.WriteString("_")
+	case s.Send != nil:
+		buff.WriteString(s.Channel.String())
+		buff.WriteString(".send(")
+		buff.WriteString(s.Send.String())
+		buff.WriteString(")")
+	default:
+		buff.WriteString(s.Pattern.String())
+		buff.WriteString(" in ")
+		buff.WriteString(s.Channel.String())
+	}
+
+	buff.WriteString(" => ")
+	buff.WriteString(s.Body.String())
+	return buff.String()
+}
+
+// SelectExpression select { arm, ... } waits until one of the channel operations of the arms can proceed and
+// evaluates to the value of its arm
+type SelectExpression struct {
+	token lexer.Token
+	Arms  []SelectArm
+}
+
+func (s SelectExpression) Token() lexer.Token {
+	return s.token
+}
+
+func (s SelectExpression) expression() {}
+
+func (s SelectExpression) String() string {
+	var buff bytes.Buffer
+	buff.WriteString("select {\n")
+	for _, arm := range s.Arms {
+		buff.WriteString(arm.String())
+		buff.WriteString(",\n")
+	}
+
+	buff.WriteString("}")
+	return buff.String()
+}
+
+// MatchExpression match value { pattern => arm, ... } evaluates to the value of the first matching arm
+type MatchExpression struct {
+	token lexer.Token
+	Value Expression
+	Arms  []MatchArm
+}
+
+func (m MatchExpression) Token() lexer.Token {
+	return m.token
+}
+
+func (m MatchExpression) expression() {}
+
+func (m MatchExpression) String() string {
+	var buff bytes.Buffer
+	buff.WriteString("match ")
+	buff.WriteString(m.Value.String())
+	buff.WriteString(" {\n")
+	for _, arm := range m.Arms {
+		buff.WriteString(arm.String())
+		buff.WriteString(",\n")
+	}
+
+	buff.WriteString("}")
+	return buff.String()
+}
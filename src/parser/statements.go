@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/charkpep/yami/src/lexer"
+	"strings"
 )
 
 type Statement interface {
@@ -11,10 +12,14 @@ type Statement interface {
 	statement()
 }
 
+// LetStatement let pattern = expr or const pattern = expr, constants can not be reassigned
 type LetStatement struct {
 	Literal    lexer.Token
-	Identifier IdentifierExpression
+	Identifier Pattern
 	Expression Expression
+	Const      bool
+	// Type optional annotation, nil if the type is not specified
+	Type TypeAnnotation
 }
 
 type ReturnStatement struct {
@@ -43,7 +48,16 @@ func (l LetStatement) Token() lexer.Token {
 func (LetStatement) statement() {}
 
 func (l LetStatement) String() string {
-	return fmt.Sprintf("let %s=%s;", l.Identifier.String(), l.Expression.String())
+	keyword := "let"
+	if l.Const {
+		keyword = "const"
+	}
+
+	if l.Type != nil {
+		return fmt.Sprintf("%s %s: %s=%s;", keyword, l.Identifier.String(), l.Type.String(), l.Expression.String())
+	}
+
+	return fmt.Sprintf("%s %s=%s;", keyword, l.Identifier.String(), l.Expression.String())
 }
 
 type BlockStatement struct {
@@ -66,3 +80,116 @@ func (b BlockStatement) String() string {
 	buff.WriteString("}")
 	return buff.String()
 }
+
+// Field of the struct declaration with optional type annotation
+type Field struct {
+	Name IdentifierExpression
+	Type TypeAnnotation
+}
+
+func (f Field) String() string {
+	if f.Type != nil {
+		return f.Name.String() + ": " + f.Type.String()
+	}
+
+	return f.Name.String()
+}
+
+// Method of the struct, the receiver is passed as the first parameter: p.norm() calls norm(p)
+type Method struct {
+	Name IdentifierExpression
+	Func FuncExpression
+}
+
+func (m Method) String() string {
+	return "fn " + m.Name.String() + strings.TrimPrefix(m.Func.String(), "fn ")
+}
+
+// StructStatement struct Point { x, y, fn norm(p) { ... } } declares the struct type, the name is bound
+// to the constructor
+type StructStatement struct {
+	token   lexer.Token
+	Name    IdentifierExpression
+	Fields  []Field
+	Methods []Method
+}
+
+func (s StructStatement) Token() lexer.Token {
+	return s.token
+}
+
+func (s StructStatement) statement() {}
+
+func (s StructStatement) String() string {
+	members := make([]string, 0, len(s.Fields)+len(s.Methods))
+	for _, field := range s.Fields {
+		members = append(members, field.String())
+	}
+
+	for _, method := range s.Methods {
+		members = append(members, method.String())
+	}
+
+	return fmt.Sprintf("struct %s { %s }", s.Name, strings.Join(members, ", "))
+}
+
+// Variant of the enum, variants without fields are values, variants with fields are constructors
+type Variant struct {
+	Name   IdentifierExpression
+	Fields []Field
+}
+
+func (v Variant) String() string {
+	if len(v.Fields) == 0 {
+		return v.Name.String()
+	}
+
+	fields := make([]string, 0, len(v.Fields))
+	for _, field := range v.Fields {
+		fields = append(fields, field.String())
+	}
+
+	return v.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+// EnumStatement enum Result { Ok(value), Err(message) } declares the enum, names of the variants are defined
+// in the same scope as the enum
+type EnumStatement struct {
+	token    lexer.Token
+	Name     IdentifierExpression
+	Variants []Variant
+}
+
+func (e EnumStatement) Token() lexer.Token {
+	return e.token
+}
+
+func (e EnumStatement) statement() {}
+
+func (e EnumStatement) String() string {
+	variants := make([]string, 0, len(e.Variants))
+	for _, variant := range e.Variants {
+		variants = append(variants, variant.String())
+	}
+
+	return fmt.Sprintf("enum %s { %s }", e.Name, strings.Join(variants, ", "))
+}
+
+// ForStatement for pattern in iterable { ... } binds every value of the iterator to the pattern, each iteration
+// gets its own scope
+type ForStatement struct {
+	token    lexer.Token
+	Pattern  Pattern
+	Iterable Expression
+	Body     BlockStatement
+}
+
+func (f ForStatement) Token() lexer.Token {
+	return f.token
+}
+
+func (f ForStatement) statement() {}
+
+func (f ForStatement) String() string {
+	return fmt.Sprintf("for %s in %s %s", f.Pattern, f.Iterable, f.Body)
+}
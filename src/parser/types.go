@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"github.com/charkpep/yami/src/lexer"
+	"strings"
+)
+
+// TypeAnnotation optional type of variables, parameters and return values, annotations are checked by the
+// types package and ignored by the evaluator
+type TypeAnnotation interface {
+	Node
+	typeAnnotation()
+}
+
+// NamedType int, float, string, bool, nil, any, fn, array, map
+type NamedType struct {
+	token lexer.Token
+	Name  string
+}
+
+func (n NamedType) Token() lexer.Token {
+	return n.token
+}
+
+func (n NamedType) typeAnnotation() {}
+
+func (n NamedType) String() string {
+	return n.Name
+}
+
+// GenericType type with parameters: array<int>, map<string, int>
+type GenericType struct {
+	token  lexer.Token
+	Name   string
+	Params []TypeAnnotation
+}
+
+func (g GenericType) Token() lexer.Token {
+	return g.token
+}
+
+func (g GenericType) typeAnnotation() {}
+
+func (g GenericType) String() string {
+	params := make([]string, 0, len(g.Params))
+	for _, param := range g.Params {
+		params = append(params, param.String())
+	}
+
+	return g.Name + "<" + strings.Join(params, ", ") + ">"
+}
+
+// UnionType value of any of the types: int | nil
+type UnionType struct {
+	Types []TypeAnnotation
+}
+
+func (u UnionType) Token() lexer.Token {
+	return u.Types[0].Token()
+}
+
+func (u UnionType) typeAnnotation() {}
+
+func (u UnionType) String() string {
+	types := make([]string, 0, len(u.Types))
+	for _, t := range u.Types {
+		types = append(types, t.String())
+	}
+
+	return strings.Join(types, " | ")
+}
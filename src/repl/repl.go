@@ -3,10 +3,12 @@ package repl
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/charkpep/yami/src/eval"
 	"github.com/charkpep/yami/src/object"
 	"github.com/charkpep/yami/src/parser"
+	"github.com/charkpep/yami/src/resolver"
 	"io"
 )
 
@@ -14,56 +16,74 @@ type Repl struct {
 	lexerIn   io.Writer
 	parser    *parser.Parser
 	env       *object.Environment
+	resolver  *resolver.Resolver
 	evaluator *eval.Evaluator
 	in        io.Reader
 	out       io.Writer
 }
 
-func New(in io.Reader, out io.Writer) *Repl {
+func New(in io.Reader, out io.Writer, opts ...eval.Option) *Repl {
 	lexerIn := bytes.NewBuffer(make([]byte, 0))
 	p := parser.NewParser(lexerIn)
 	env := object.NewEnv()
-	e := eval.NewEvaluator()
+	e := eval.NewEvaluator(opts...)
 	return &Repl{
 		lexerIn:   lexerIn,
 		evaluator: e,
 		env:       env,
+		resolver:  resolver.New(),
 		parser:    p,
 		in:        in,
 		out:       out,
 	}
 }
 
-func (r Repl) Start() {
+// Start reads and evaluates lines until the end of the input or exit, it returns the exit code
+func (r Repl) Start() int {
 	s := bufio.NewScanner(r.in)
 	for {
-		fmt.Print(">> ")
+		fmt.Fprint(r.out, ">> ")
 		if !s.Scan() {
-			return
+			return 0
 		}
 
 		r.lexerIn.Write(s.Bytes())
 		root, err := r.parser.Parse()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(r.out, err)
 			continue
 		}
 
 		if len(r.parser.Errors) != 0 {
-			fmt.Println(r.parser.Errors)
+			fmt.Fprintln(r.out, r.parser.Errors)
+			continue
+		}
+
+		diagnostics := r.resolver.Resolve(root).Diagnostics
+		for _, d := range diagnostics {
+			fmt.Fprintln(r.out, d)
+		}
+
+		if resolver.HasErrors(diagnostics) {
 			continue
 		}
 
 		obj, err := r.evaluator.EvalWithEnv(root, r.env)
+		var exit *object.ExitError
+		if errors.As(err, &exit) {
+			return exit.Code
+		}
+
 		if err != nil {
-			fmt.Println(err)
+			r.resolver.Forget()
+			fmt.Fprintln(r.out, err)
 			continue
 		}
 
 		if obj != nil {
-			fmt.Println(obj.Inspect())
+			fmt.Fprintln(r.out, obj.Inspect())
 		} else {
-			fmt.Println("Nil")
+			fmt.Fprintln(r.out, "Nil")
 		}
 
 	}
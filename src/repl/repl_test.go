@@ -0,0 +1,47 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	type tt struct {
+		in  string
+		out []string
+	}
+
+	ts := []tt{
+		{"let x = 1\nx + 1", []string{"1", "2"}},
+		// names of the failed input can be defined again
+		{"let x = 1 / 0\nlet x = 2\nx", []string{"zero division", "2", "2"}},
+		{"let x = y\nlet x = 2\nx", []string{"not defined", "2", "2"}},
+		{"let x = 1\nlet x = 2\nx", []string{"1", "identifier x is already defined in this scope", "1"}},
+	}
+
+	for _, test := range ts {
+		var out bytes.Buffer
+		if code := New(strings.NewReader(test.in), &out).Start(); code != 0 {
+			t.Fatalf("expected exit code 0, got %d", code)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(strings.TrimPrefix(out.String(), ">> "), ">> "), "\n>> ")
+		if len(lines) != len(test.out) {
+			t.Fatalf("expected %d outputs for %q, got %q", len(test.out), test.in, out.String())
+		}
+
+		for i, line := range lines {
+			if !strings.Contains(line, test.out[i]) {
+				t.Fatalf("expected output containing %q for %q, got %q", test.out[i], test.in, line)
+			}
+		}
+	}
+}
+
+func TestStartExit(t *testing.T) {
+	var out bytes.Buffer
+	if code := New(strings.NewReader("1\nexit(3)\n2"), &out).Start(); code != 3 {
+		t.Fatalf("expected exit code 3, got %d", code)
+	}
+}
@@ -0,0 +1,493 @@
+package resolver
+
+import (
+	"fmt"
+	"github.com/charkpep/yami/src/lexer"
+	"github.com/charkpep/yami/src/object"
+	"github.com/charkpep/yami/src/parser"
+	"sort"
+	"strings"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	if s == Warning {
+		return "Warning"
+	}
+
+	return "Error"
+}
+
+// Diagnostic problem found before evaluation, programs with Error diagnostics must not be evaluated
+type Diagnostic struct {
+	Severity Severity
+	Msg      string
+	Token    lexer.Token
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s | line: %d, column: %d | message: %s | token: %s", d.Severity, d.Token.Line, d.Token.Column, d.Msg, d.Token.Literal)
+}
+
+// HasErrors reports whether any of the diagnostics is an Error
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == Error {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ScopeKind where the identifier is defined relatively to the place it is used
+type ScopeKind int
+
+const (
+	// Local defined by the function using it, or by a block of the top level code
+	Local ScopeKind = iota
+	// Closure defined by one of the enclosing functions
+	Closure
+	// Global defined at the top level
+	Global
+	// BuildIn build in function or namespace
+	BuildIn
+)
+
+func (k ScopeKind) String() string {
+	switch k {
+	case Local:
+		return "local"
+	case Closure:
+		return "closure"
+	case Global:
+		return "global"
+	default:
+		return "build in"
+	}
+}
+
+type symbol struct {
+	token    lexer.Token
+	slot     int
+	constant bool
+	used     bool
+	// parameters and top level names are not reported as unused
+	reportUnused bool
+}
+
+// scope mirrors the environments created by the evaluator: top level, blocks, branches of if, match arms,
+// iterations of for and function calls. Variables of all scopes except the top level one are assigned to slots in the order
+// of definition.
+type scope struct {
+	parent *scope
+	// function owning the scope, 0 for the top level code
+	function int
+	symbols  map[string]*symbol
+	slots    int
+}
+
+func newScope(parent *scope, function int) *scope {
+	return &scope{
+		parent:   parent,
+		function: function,
+		symbols:  make(map[string]*symbol),
+	}
+}
+
+type deferredFunc struct {
+	fn    parser.FuncExpression
+	scope *scope
+}
+
+// Result of the resolution, Scopes maps identifier tokens to the kind of the scope defining them
+type Result struct {
+	Diagnostics []Diagnostic
+	Scopes      map[lexer.Token]ScopeKind
+}
+
+// Resolver static analysis pass resolving every identifier before evaluation. Names defined at the top level
+// are kept between calls to Resolve, so the REPL input can refer to the names from the previous lines.
+type Resolver struct {
+	globals   *scope
+	functions int
+
+	// state of the current Resolve call
+	deferred    []deferredFunc
+	declared    []*symbol
+	result      Result
+	globalNames []string
+}
+
+func New() *Resolver {
+	return &Resolver{
+		globals: newScope(nil, 0),
+	}
+}
+
+// Resolve checks the program for undefined identifiers, duplicate definitions in the same scope, assignment
+// to constants, return outside functions and unused variables. Function bodies are resolved after the
+// enclosing code, as they can refer to names defined after the function, e.g. recursive functions.
+func (r *Resolver) Resolve(node parser.Node) Result {
+	r.deferred = nil
+	r.declared = nil
+	r.globalNames = nil
+	r.result = Result{
+		Scopes: make(map[lexer.Token]ScopeKind),
+	}
+
+	switch v := node.(type) {
+	case *parser.RootNode:
+		r.statements(v.Statements, r.globals)
+	case parser.Statement:
+		r.statement(v, r.globals)
+	}
+
+	for len(r.deferred) > 0 {
+		fn := r.deferred[0]
+		r.deferred = r.deferred[1:]
+		r.function(fn.fn, fn.scope)
+	}
+
+	for _, sym := range r.declared {
+		if sym.reportUnused && !sym.used && !strings.HasPrefix(sym.token.Literal, "_") {
+			r.report(Warning, fmt.Sprintf("%s is defined but never used", sym.token.Literal), sym.token)
+		}
+	}
+
+	if HasErrors(r.result.Diagnostics) {
+		// the program is not evaluated, so its top level names must not be visible to the next input
+		r.Forget()
+	}
+
+	sort.SliceStable(r.result.Diagnostics, func(i, j int) bool {
+		a, b := r.result.Diagnostics[i].Token, r.result.Diagnostics[j].Token
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+
+		return a.Column < b.Column
+	})
+
+	return r.result
+}
+
+// Forget removes the top level names defined by the last Resolve call, e.g. when the evaluation of the REPL
+// input fails, so the names can be defined again by the next input
+func (r *Resolver) Forget() {
+	for _, name := range r.globalNames {
+		delete(r.globals.symbols, name)
+	}
+
+	r.globalNames = nil
+}
+
+func (r *Resolver) report(severity Severity, msg string, token lexer.Token) {
+	r.result.Diagnostics = append(r.result.Diagnostics, Diagnostic{
+		Severity: severity,
+		Msg:      msg,
+		Token:    token,
+	})
+}
+
+func (r *Resolver) declare(s *scope, ident parser.IdentifierExpression, constant, reportUnused bool) {
+	name := ident.Identifier.Literal
+	if _, ok := s.symbols[name]; ok {
+		r.report(Error, fmt.Sprintf("identifier %s is already defined in this scope", name), ident.Identifier)
+		return
+	}
+
+	sym := &symbol{
+		token:        ident.Identifier,
+		constant:     constant,
+		reportUnused: reportUnused && s.parent != nil,
+	}
+
+	// top level names are looked up by name, so the REPL can define them one by one
+	if s.parent != nil {
+		sym.slot = s.slots
+		s.slots++
+		resolve(ident, 0, sym.slot)
+	}
+
+	s.symbols[name] = sym
+	r.declared = append(r.declared, sym)
+	if s == r.globals {
+		r.globalNames = append(r.globalNames, name)
+	}
+}
+
+// declarePattern defines identifiers of the pattern, identifiers of the alternatives share the slots
+// of the first alternative
+func (r *Resolver) declarePattern(s *scope, pattern parser.Pattern, constant, reportUnused bool) {
+	for _, ident := range parser.PatternIdentifiers(pattern) {
+		r.declare(s, ident, constant, reportUnused)
+	}
+
+	if s.parent == nil {
+		return
+	}
+
+	for _, ident := range parser.PatternBindings(pattern) {
+		if sym, ok := s.symbols[ident.Identifier.Literal]; ok {
+			resolve(ident, 0, sym.slot)
+		}
+	}
+}
+
+// usePattern resolves names of the enum variants matched by the pattern
+func (r *Resolver) usePattern(s *scope, pattern parser.Pattern) {
+	switch p := pattern.(type) {
+	case parser.VariantPattern:
+		r.use(s, p.Name)
+		for _, field := range p.Fields {
+			r.usePattern(s, field)
+		}
+	case parser.ArrayPattern:
+		for _, element := range p.Elements {
+			r.usePattern(s, element)
+		}
+	case parser.MapPattern:
+		for _, entry := range p.Entries {
+			r.usePattern(s, entry.Pattern)
+		}
+	case parser.AlternativePattern:
+		for _, alternative := range p.Alternatives {
+			r.usePattern(s, alternative)
+		}
+	}
+}
+
+func resolve(ident parser.IdentifierExpression, depth, slot int) {
+	if ident.Resolution != nil {
+		*ident.Resolution = parser.Resolution{
+			Resolved: true,
+			Depth:    depth,
+			Slot:     slot,
+		}
+	}
+}
+
+func (r *Resolver) lookup(s *scope, name string) (*symbol, *scope, int) {
+	for depth := 0; s != nil; s, depth = s.parent, depth+1 {
+		if sym, ok := s.symbols[name]; ok {
+			return sym, s, depth
+		}
+	}
+
+	return nil, nil, 0
+}
+
+func (r *Resolver) use(s *scope, ident parser.IdentifierExpression) {
+	if !r.tryUse(s, ident) {
+		r.report(Error, fmt.Sprintf("identifier %s is not defined", ident.Identifier.Literal), ident.Identifier)
+	}
+}
+
+// tryUse resolves the identifier, false is returned if it is not defined
+func (r *Resolver) tryUse(s *scope, ident parser.IdentifierExpression) bool {
+	name := ident.Identifier.Literal
+	sym, defining, depth := r.lookup(s, name)
+	switch {
+	case sym != nil:
+		sym.used = true
+		switch {
+		case defining.parent == nil:
+			r.result.Scopes[ident.Identifier] = Global
+			return true
+		case defining.function == s.function:
+			r.result.Scopes[ident.Identifier] = Local
+		default:
+			r.result.Scopes[ident.Identifier] = Closure
+		}
+
+		resolve(ident, depth, sym.slot)
+	case object.BuildIns[name] != nil || object.BuildInNamespaces[name] != nil:
+		r.result.Scopes[ident.Identifier] = BuildIn
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (r *Resolver) statements(stmts []parser.Statement, s *scope) {
+	for _, stmt := range stmts {
+		r.statement(stmt, s)
+	}
+}
+
+func (r *Resolver) statement(stmt parser.Statement, s *scope) {
+	switch v := stmt.(type) {
+	case parser.LetStatement:
+		// the value is resolved before the names are defined, let x = x is an error
+		r.expression(v.Expression, s)
+		r.usePattern(s, v.Identifier)
+		r.declarePattern(s, v.Identifier, v.Const, true)
+	case parser.ReturnStatement:
+		if s.function == 0 {
+			r.report(Error, "return outside function", v.Token())
+		}
+
+		r.expression(v.ReturnExpr, s)
+	case parser.BlockStatement:
+		r.statements(v.Statements, newScope(s, s.function))
+	case parser.StructStatement:
+		r.declare(s, v.Name, true, true)
+		for _, method := range v.Methods {
+			r.expression(method.Func, s)
+		}
+	case parser.EnumStatement:
+		r.declare(s, v.Name, true, true)
+		for _, variant := range v.Variants {
+			r.declare(s, variant.Name, true, false)
+		}
+	case parser.ForStatement:
+		r.expression(v.Iterable, s)
+		iterScope := newScope(s, s.function)
+		r.usePattern(iterScope, v.Pattern)
+		r.declarePattern(iterScope, v.Pattern, false, true)
+		r.statements(v.Body.Statements, iterScope)
+	case parser.ExpressionStatement:
+		r.expression(v.Expr, s)
+	}
+}
+
+func (r *Resolver) expression(expr parser.Expression, s *scope) {
+	switch v := expr.(type) {
+	case nil:
+	case parser.IdentifierExpression:
+		r.use(s, v)
+	case *parser.InfixExpression:
+		r.expression(v.Left, s)
+		r.expression(v.Right, s)
+	case parser.PrefixExpression:
+		r.expression(v.Expr, s)
+	case parser.IfExpression:
+		r.expression(v.Condition, s)
+		r.statements(v.Consequence.Statements, newScope(s, s.function))
+		if v.Alternative != nil {
+			r.statements(v.Alternative.Statements, newScope(s, s.function))
+		}
+	case parser.MatchExpression:
+		r.expression(v.Value, s)
+		for _, arm := range v.Arms {
+			armScope := newScope(s, s.function)
+			r.usePattern(armScope, arm.Pattern)
+			r.declarePattern(armScope, arm.Pattern, false, true)
+
+			r.expression(arm.Guard, armScope)
+			r.statements(arm.Body.Statements, armScope)
+		}
+	case parser.FuncExpression:
+		r.deferred = append(r.deferred, deferredFunc{fn: v, scope: s})
+	case parser.YieldExpression:
+		if s.function == 0 {
+			r.report(Error, "yield outside function", v.Token())
+		}
+
+		r.expression(v.Val, s)
+	case parser.CallExpression:
+		if member, ok := v.Call.(parser.MemberExpression); ok {
+			// x.f() may call the function f with x as the first argument, unless x has the method f,
+			// so the name is resolved only if it is defined
+			r.expression(member.Of, s)
+			r.tryUse(s, member.Field)
+		} else {
+			r.expression(v.Call, s)
+		}
+
+		for _, arg := range v.CallArgs {
+			r.expression(arg, s)
+		}
+	case parser.PipeExpression:
+		call, ok := v.MethodCall()
+		if !ok {
+			r.expression(v.Left, s)
+			r.expression(v.Right, s)
+			break
+		}
+
+		// x |> f() calls the build in method f of x if the function f is not defined
+		r.expression(v.Left, s)
+		r.tryUse(s, call.Call.(parser.MemberExpression).Field)
+		for _, arg := range call.CallArgs {
+			r.expression(arg, s)
+		}
+	case parser.SpawnExpression:
+		r.expression(v.Call, s)
+	case parser.AwaitExpression:
+		r.expression(v.Task, s)
+	case parser.SelectExpression:
+		for _, arm := range v.Arms {
+			r.expression(arm.Channel, s)
+			r.expression(arm.Send, s)
+		}
+
+		for _, arm := range v.Arms {
+			armScope := newScope(s, s.function)
+			if arm.Pattern != nil {
+				r.usePattern(armScope, arm.Pattern)
+				r.declarePattern(armScope, arm.Pattern, false, true)
+			}
+
+			r.statements(arm.Body.Statements, armScope)
+		}
+	case parser.SpreadExpression:
+		r.expression(v.Val, s)
+	case parser.NamedArgument:
+		r.expression(v.Val, s)
+	case parser.AssignExpression:
+		if ident, ok := v.Identifier.(parser.IdentifierExpression); ok {
+			if sym, _, _ := r.lookup(s, ident.Identifier.Literal); sym != nil && sym.constant {
+				r.report(Error, fmt.Sprintf("can not assign to constant %s", ident.Identifier.Literal), ident.Identifier)
+			}
+		}
+
+		r.expression(v.Identifier, s)
+		r.expression(v.Val, s)
+	case parser.IndexExpression:
+		r.expression(v.Of, s)
+		r.expression(v.Idx, s)
+	case parser.MemberExpression:
+		r.expression(v.Of, s)
+	case parser.StructExpression:
+		r.use(s, v.Name)
+		for _, field := range v.Fields {
+			r.expression(field.Val, s)
+		}
+	case parser.SliceExpression:
+		r.expression(v.Of, s)
+		r.expression(v.Start, s)
+		r.expression(v.End, s)
+	case parser.ArrayExpression:
+		for _, element := range v.Arr {
+			r.expression(element, s)
+		}
+	case parser.HashMapExpression:
+		for key, val := range v.Map {
+			r.expression(key, s)
+			r.expression(val, s)
+		}
+	}
+}
+
+// function resolves parameters and the body in the scope of the call, default values can refer to the
+// preceding parameters
+func (r *Resolver) function(fn parser.FuncExpression, parent *scope) {
+	r.functions++
+	s := newScope(parent, r.functions)
+	for _, param := range fn.Args {
+		r.expression(param.Default, s)
+		r.usePattern(s, param.Pattern)
+		r.declarePattern(s, param.Pattern, false, false)
+	}
+
+	r.statements(fn.Body.Statements, s)
+}
@@ -0,0 +1,204 @@
+package resolver
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/charkpep/yami/src/parser"
+	"testing"
+)
+
+func parse(t *testing.T, in string) parser.Node {
+	p := parser.NewParser(bytes.NewBufferString(in))
+	root, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Errors) != 0 {
+		t.Fatal(p.Errors)
+	}
+
+	return root
+}
+
+func TestDiagnostics(t *testing.T) {
+	type tt struct {
+		in          string
+		diagnostics []string
+	}
+
+	ts := []tt{
+		{"let x = 1; x", nil},
+		{"let fib = fn(n) { if n < 2 { return n }; fib(n - 1) + fib(n - 2) }; fib(10)", nil},
+		{"let f = fn() { g() }; let g = fn() { 1 }; f()", nil},
+		{"let f = fn(x, step = x) { x + step }; f(1)", nil},
+		{"let f = fn([a, b], ...rest) { 1 }; f([1, 2])", nil},
+		{"len([1]) + math[\"abs\"](-1)", nil},
+		{"let len = fn(x) { 0 }; len(1)", nil},
+		{"let x = 1; match x { [a, b] if a > b => a, n => n }", nil},
+		{"let i = 1; let f = fn() { let i = 2; i }; f() + i", nil},
+		{"let f = fn() { let _tmp = 1; 2 }; f()", nil},
+		{"y", []string{"Error | line: 0, column: 1 | message: identifier y is not defined | token: y"}},
+		{"let x = x", []string{"identifier x is not defined"}},
+		{"let f = fn() { typo + 1 }; f()", []string{"identifier typo is not defined"}},
+		{"if true { let y = 1; y }; y", []string{"identifier y is not defined"}},
+		{"let x = 1; let x = 2; x", []string{"identifier x is already defined in this scope"}},
+		{"let [a, a] = [1, 2]; a", []string{"identifier a is already defined in this scope"}},
+		{"let f = fn(a, a) { a }; f(1, 2)", []string{"identifier a is already defined in this scope"}},
+		{"const x = 1; x = 2", []string{"can not assign to constant x"}},
+		{"return 1", []string{"return outside function"}},
+		{"if true { return 1 }", []string{"return outside function"}},
+		{"let f = fn() { let unused = 1; 2 }; f()", []string{"Warning | line: 0, column: 25 | message: unused is defined but never used | token: unused"}},
+		{"match [1, 2] { [a, b] => a }", []string{"b is defined but never used"}},
+		{"z; let f = fn() { w }; f()", []string{"identifier z is not defined", "identifier w is not defined"}},
+		{"struct P { x }; let p = P{x: 1}; p.x = P(2).x", nil},
+		{"let f = fn() { struct Local { a }; Local(1).a }; f()", nil},
+		{"P(1)", []string{"identifier P is not defined"}},
+		{"let p = Q{x: y}", []string{"identifier Q is not defined", "identifier y is not defined"}},
+		{"struct P { x }; P = 1", []string{"can not assign to constant P"}},
+		{"let f = fn() { struct Unused { a }; 1 }; f()", []string{"Unused is defined but never used"}},
+		{"let f = fn() { let inc = fn(x) { x + 1 }; 1.inc() }; f()", nil},
+		{"[1].push(2).map(fn(x) { x })", nil},
+		{"struct P { x, fn get(self) { P(self.x) } }; P(1).get()", nil},
+		{"struct P { x, fn get(self) { self.x + y } }", []string{"identifier y is not defined"}},
+		{"enum R { Ok(v), Err(m) }; let f = fn(r) { match r { Ok(v) => v, Err(_) => 0 } }; f(Ok(1))", nil},
+		{"let f = fn() { enum Local { A, B(x) }; let B(y) = Local.B(A); y }; f()", nil},
+		{"match x { Some(v) => v }", []string{"identifier x is not defined", "identifier Some is not defined"}},
+		{"enum R { Ok(v) }; Ok = 1", []string{"can not assign to constant Ok"}},
+		{"let Ok = 1; enum R { Ok(v) }", []string{"identifier Ok is already defined in this scope"}},
+		{"let gen = fn(xs) { for [a, b] in xs { yield a + b } }; gen([])", nil},
+		{"for x in [1] { let y = x }", []string{"y is defined but never used"}},
+		{"for x in [1] { x }; x", []string{"identifier x is not defined"}},
+		{"yield 1", []string{"yield outside function"}},
+		{"let f = fn() { let inc = fn(x) { x + 1 }; [1] |> map(inc) |> len() }; f()", nil},
+		{"1 |> g; [1] |> f(y)", []string{"identifier g is not defined", "identifier y is not defined"}},
+		{"let ch = channel(1); let f = fn(x) { x }; await spawn f(1); select { x in ch => x, ch.send(1) => 2, _ => 3 }", nil},
+		{"select { x in ch => x + y }; x", []string{"identifier ch is not defined", "identifier y is not defined", "identifier x is not defined"}},
+	}
+
+	for i, test := range ts {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			result := New().Resolve(parse(t, test.in))
+			if len(result.Diagnostics) != len(test.diagnostics) {
+				t.Fatalf("expected %d diagnostics, got %v", len(test.diagnostics), result.Diagnostics)
+			}
+
+			for j, d := range result.Diagnostics {
+				if !bytes.Contains([]byte(d.Error()), []byte(test.diagnostics[j])) {
+					t.Errorf("expected diagnostic %q, got %q", test.diagnostics[j], d.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestScopes(t *testing.T) {
+	root := parse(t, "let g = 1; let outer = fn(a) { let inner = fn() { a + g }; len(inner()) + a }; outer(1)")
+	result := New().Resolve(root)
+	if len(result.Diagnostics) != 0 {
+		t.Fatal(result.Diagnostics)
+	}
+
+	expected := map[string][]ScopeKind{
+		"a":     {Closure, Local},
+		"g":     {Global},
+		"len":   {BuildIn},
+		"inner": {Local},
+		"outer": {Global},
+	}
+
+	got := make(map[string][]ScopeKind)
+	for token, kind := range result.Scopes {
+		got[token.Literal] = append(got[token.Literal], kind)
+	}
+
+	for name, kinds := range expected {
+		if len(got[name]) != len(kinds) {
+			t.Fatalf("expected %v for %s, got %v", kinds, name, got[name])
+		}
+
+		for _, kind := range kinds {
+			found := false
+			for _, k := range got[name] {
+				found = found || k == kind
+			}
+
+			if !found {
+				t.Errorf("expected %s to be resolved as %s, got %v", name, kind, got[name])
+			}
+		}
+	}
+}
+
+func TestReplKeepsGlobals(t *testing.T) {
+	r := New()
+	if d := r.Resolve(parse(t, "let x = 1")).Diagnostics; len(d) != 0 {
+		t.Fatal(d)
+	}
+
+	if d := r.Resolve(parse(t, "let y = 1; undefined")).Diagnostics; !HasErrors(d) {
+		t.Fatal("expected error")
+	}
+
+	if d := r.Resolve(parse(t, "x")).Diagnostics; len(d) != 0 {
+		t.Fatal(d)
+	}
+
+	if d := r.Resolve(parse(t, "y")).Diagnostics; !HasErrors(d) {
+		t.Fatal("names of the input with errors must not be defined")
+	}
+}
+
+func TestSlots(t *testing.T) {
+	root := parse(t, "let g = 1; let f = fn(a, b) { { let c = a; fn() { b + c + g } } }")
+	if d := New().Resolve(root).Diagnostics; len(d) != 0 {
+		t.Fatal(d)
+	}
+
+	expected := map[string][]parser.Resolution{
+		// definitions of a, b and the use of a in let c = a
+		"a": {{Resolved: true, Depth: 0, Slot: 0}, {Resolved: true, Depth: 1, Slot: 0}},
+		"b": {{Resolved: true, Depth: 0, Slot: 1}, {Resolved: true, Depth: 2, Slot: 1}},
+		"c": {{Resolved: true, Depth: 0, Slot: 0}, {Resolved: true, Depth: 1, Slot: 0}},
+		// globals are looked up by name
+		"g": {{}, {}},
+	}
+
+	got := make(map[string][]parser.Resolution)
+	var collect func(node parser.Node)
+	collect = func(node parser.Node) {
+		switch v := node.(type) {
+		case *parser.RootNode:
+			for _, stmt := range v.Statements {
+				collect(stmt)
+			}
+		case parser.LetStatement:
+			collect(v.Identifier)
+			collect(v.Expression)
+		case parser.ExpressionStatement:
+			collect(v.Expr)
+		case parser.BlockStatement:
+			for _, stmt := range v.Statements {
+				collect(stmt)
+			}
+		case parser.FuncExpression:
+			for _, arg := range v.Args {
+				collect(arg.Pattern)
+			}
+
+			collect(v.Body)
+		case *parser.InfixExpression:
+			collect(v.Left)
+			collect(v.Right)
+		case parser.IdentifierExpression:
+			got[v.Identifier.Literal] = append(got[v.Identifier.Literal], *v.Resolution)
+		}
+	}
+
+	collect(root)
+	for name, resolutions := range expected {
+		if fmt.Sprint(got[name]) != fmt.Sprint(resolutions) {
+			t.Errorf("expected %v for %s, got %v", resolutions, name, got[name])
+		}
+	}
+}
@@ -0,0 +1,829 @@
+package types
+
+import (
+	"fmt"
+	"github.com/charkpep/yami/src/parser"
+	"github.com/charkpep/yami/src/resolver"
+	"strings"
+)
+
+type scope struct {
+	parent *scope
+	vars   map[string]Type
+	// types declared in the scope, e.g. structs
+	types map[string]Type
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{
+		parent: parent,
+		vars:   make(map[string]Type),
+		types:  make(map[string]Type),
+	}
+}
+
+func (s *scope) lookup(name string) Type {
+	for ; s != nil; s = s.parent {
+		if t, ok := s.vars[name]; ok {
+			return t
+		}
+	}
+
+	return Any
+}
+
+func (s *scope) lookupType(name string) (Type, bool) {
+	for ; s != nil; s = s.parent {
+		if t, ok := s.types[name]; ok {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
+
+// Checker checks annotated code against the annotations. Unannotated variables are dynamic and have type
+// any, except constants and functions which take the type of the value.
+type Checker struct {
+	diagnostics []resolver.Diagnostic
+	// result type of the function being checked, nil for the top level code
+	result Type
+}
+
+// Check type checks the program, matches over enums missing some of the variants are reported as Warning,
+// everything else as Error
+func Check(node parser.Node) []resolver.Diagnostic {
+	c := &Checker{}
+	s := newScope(nil)
+	switch v := node.(type) {
+	case *parser.RootNode:
+		c.statements(v.Statements, s)
+	case parser.Statement:
+		c.statement(v, s)
+	}
+
+	return c.diagnostics
+}
+
+func (c *Checker) report(msg string, node parser.Node) {
+	c.diagnostics = append(c.diagnostics, resolver.Diagnostic{
+		Severity: resolver.Error,
+		Msg:      msg,
+		Token:    node.Token(),
+	})
+}
+
+func (c *Checker) warn(msg string, node parser.Node) {
+	c.diagnostics = append(c.diagnostics, resolver.Diagnostic{
+		Severity: resolver.Warning,
+		Msg:      msg,
+		Token:    node.Token(),
+	})
+}
+
+func (c *Checker) annotation(annotation parser.TypeAnnotation, s *scope) Type {
+	t, err := ResolveAnnotation(annotation, s.lookupType)
+	if err != nil {
+		c.report(err.Error(), annotation)
+		return Any
+	}
+
+	return t
+}
+
+func (c *Checker) statements(stmts []parser.Statement, s *scope) {
+	for _, stmt := range stmts {
+		c.statement(stmt, s)
+	}
+}
+
+func (c *Checker) statement(stmt parser.Statement, s *scope) {
+	switch v := stmt.(type) {
+	case parser.LetStatement:
+		val := c.expression(v.Expression, s)
+		_, isFunc := v.Expression.(parser.FuncExpression)
+		switch {
+		case v.Type != nil:
+			declared := c.annotation(v.Type, s)
+			if !AssignableTo(val, declared) {
+				c.report(fmt.Sprintf("can not assign %s to %s of type %s", val, v.Identifier, declared), v.Expression)
+			}
+
+			c.bind(s, v.Identifier, declared)
+		case v.Const || isFunc:
+			c.bind(s, v.Identifier, val)
+		default:
+			c.bind(s, v.Identifier, Any)
+		}
+	case parser.ReturnStatement:
+		val := c.expression(v.ReturnExpr, s)
+		if c.result != nil && !AssignableTo(val, c.result) {
+			c.report(fmt.Sprintf("can not return %s from function returning %s", val, c.result), v)
+		}
+	case parser.BlockStatement:
+		c.statements(v.Statements, newScope(s))
+	case parser.ExpressionStatement:
+		c.expression(v.Expr, s)
+	case parser.StructStatement:
+		// the struct is declared before its fields, so fields can refer to the struct itself
+		st := &Struct{Name: v.Name.Identifier.Literal, Methods: make(map[string]Func)}
+		s.types[st.Name] = st
+		for _, field := range v.Fields {
+			st.Fields = append(st.Fields, Param{Name: field.Name.Identifier.Literal, Type: c.annotation(field.Type, s)})
+		}
+
+		s.vars[st.Name] = Func{Params: st.Fields, Result: st}
+		for _, method := range v.Methods {
+			fn := c.function(method.Func, s).(Func)
+			fn.Params = fn.Params[1:]
+			st.Methods[method.Name.Identifier.Literal] = fn
+		}
+	case parser.ForStatement:
+		elem := Type(Any)
+		switch t := c.expression(v.Iterable, s); {
+		case t == String:
+			elem = String
+		case t == Range:
+			elem = Int
+		case isArray(t):
+			elem = t.(Array).Elem
+		}
+
+		iterScope := newScope(s)
+		c.bind(iterScope, v.Pattern, elem)
+		c.statements(v.Body.Statements, iterScope)
+	case parser.EnumStatement:
+		en := &Enum{Name: v.Name.Identifier.Literal}
+		s.types[en.Name] = en
+		for _, variant := range v.Variants {
+			t := Variant{Name: variant.Name.Identifier.Literal}
+			for _, field := range variant.Fields {
+				t.Fields = append(t.Fields, Param{Name: field.Name.Identifier.Literal, Type: c.annotation(field.Type, s)})
+			}
+
+			en.Variants = append(en.Variants, t)
+			if t.Fields == nil {
+				s.vars[t.Name] = en
+			} else {
+				s.vars[t.Name] = Func{Params: t.Fields, Result: en}
+			}
+		}
+	}
+}
+
+// bind defines identifiers of the pattern, parts of arrays and maps take the element type
+func (c *Checker) bind(s *scope, pattern parser.Pattern, t Type) {
+	switch p := pattern.(type) {
+	case parser.IdentifierExpression:
+		s.vars[p.Identifier.Literal] = t
+	case parser.ArrayPattern:
+		elem := Type(Any)
+		if arr, ok := t.(Array); ok {
+			elem = arr.Elem
+		}
+
+		for _, element := range p.Elements {
+			c.bind(s, element, elem)
+		}
+
+		if p.Rest != nil {
+			c.bind(s, *p.Rest, Array{Elem: elem})
+		}
+	case parser.MapPattern:
+		val := Type(Any)
+		if mp, ok := t.(Map); ok {
+			val = mp.Val
+		}
+
+		for _, entry := range p.Entries {
+			c.bind(s, entry.Pattern, val)
+		}
+	case parser.VariantPattern:
+		// fields take the types of the variant declaration
+		en, _ := variantEnum(s.lookup(p.Name.Identifier.Literal))
+		variant, _ := en.Variant(p.Name.Identifier.Literal)
+		for i, field := range p.Fields {
+			t := Type(Any)
+			if i < len(variant.Fields) {
+				t = variant.Fields[i].Type
+			}
+
+			c.bind(s, field, t)
+		}
+	default:
+		for _, ident := range parser.PatternIdentifiers(pattern) {
+			s.vars[ident.Identifier.Literal] = Any
+		}
+	}
+}
+
+func (c *Checker) expression(expr parser.Expression, s *scope) Type {
+	switch v := expr.(type) {
+	case parser.IntegerExpression, parser.BigIntegerExpression:
+		return Int
+	case parser.FloatExpression:
+		return Float
+	case parser.StringExpression:
+		return String
+	case parser.BoolExpression:
+		return Bool
+	case parser.NilExpression:
+		return Nil
+	case parser.IdentifierExpression:
+		return s.lookup(v.Identifier.Literal)
+	case parser.PrefixExpression:
+		return c.prefix(v, c.expression(v.Expr, s))
+	case *parser.InfixExpression:
+		return c.infix(v, c.expression(v.Left, s), c.expression(v.Right, s))
+	case parser.IfExpression:
+		c.expression(v.Condition, s)
+		c.statements(v.Consequence.Statements, newScope(s))
+		if v.Alternative != nil {
+			c.statements(v.Alternative.Statements, newScope(s))
+		}
+	case parser.MatchExpression:
+		c.exhaustive(v, c.expression(v.Value, s), s)
+		for _, arm := range v.Arms {
+			armScope := newScope(s)
+			c.bind(armScope, arm.Pattern, Any)
+			if arm.Guard != nil {
+				c.expression(arm.Guard, armScope)
+			}
+
+			c.statements(arm.Body.Statements, armScope)
+		}
+	case parser.FuncExpression:
+		return c.function(v, s)
+	case parser.YieldExpression:
+		c.expression(v.Val, s)
+		return Nil
+	case parser.CallExpression:
+		return c.call(v, s)
+	case parser.PipeExpression:
+		return c.call(v.Call(), s)
+	case parser.SpawnExpression:
+		c.call(v.Call, s)
+		return Task
+	case parser.AwaitExpression:
+		if task := c.expression(v.Task, s); !AssignableTo(task, Task) {
+			c.report(fmt.Sprintf("can not await %s", task), v)
+		}
+	case parser.SelectExpression:
+		for _, arm := range v.Arms {
+			if arm.Channel != nil {
+				if ch := c.expression(arm.Channel, s); !AssignableTo(ch, Chan) {
+					c.report(fmt.Sprintf("expected channel, got %s", ch), arm.Channel)
+				}
+			}
+
+			if arm.Send != nil {
+				c.expression(arm.Send, s)
+			}
+
+			armScope := newScope(s)
+			if arm.Pattern != nil {
+				c.bind(armScope, arm.Pattern, Any)
+			}
+
+			c.statements(arm.Body.Statements, armScope)
+		}
+	case parser.AssignExpression:
+		return c.assign(v, s)
+	case parser.IndexExpression:
+		return c.index(v, s)
+	case parser.MemberExpression:
+		return c.member(v, s)
+	case parser.StructExpression:
+		return c.structLiteral(v, s)
+	case parser.SliceExpression:
+		of := c.expression(v.Of, s)
+		if v.Start != nil {
+			c.expression(v.Start, s)
+		}
+
+		if v.End != nil {
+			c.expression(v.End, s)
+		}
+
+		return of
+	case parser.ArrayExpression:
+		elements := make([]Type, 0, len(v.Arr))
+		for _, element := range v.Arr {
+			elements = append(elements, c.expression(element, s))
+		}
+
+		return Array{Elem: join(elements)}
+	case parser.HashMapExpression:
+		keys := make([]Type, 0, len(v.Map))
+		vals := make([]Type, 0, len(v.Map))
+		for key, val := range v.Map {
+			keys = append(keys, c.expression(key, s))
+			vals = append(vals, c.expression(val, s))
+		}
+
+		return Map{Key: join(keys), Val: join(vals)}
+	case parser.SpreadExpression:
+		return c.expression(v.Val, s)
+	case parser.NamedArgument:
+		return c.expression(v.Val, s)
+	}
+
+	return Any
+}
+
+// variantEnum returns the enum of the variant given the type of its name, the variant is either the enum value
+// or its constructor
+func variantEnum(t Type) (*Enum, bool) {
+	if fn, ok := t.(Func); ok {
+		t = fn.Result
+	}
+
+	en, ok := t.(*Enum)
+	if !ok {
+		return &Enum{}, false
+	}
+
+	return en, true
+}
+
+// exhaustive warns when the match over the enum does not cover all of its variants. The enum is the type
+// of the value, or the enum of the variants in the patterns if the value is not annotated. Arms with
+// guards cover nothing, as the guard may be false.
+func (c *Checker) exhaustive(match parser.MatchExpression, val Type, s *scope) {
+	en, ok := val.(*Enum)
+	for i := 0; !ok && i < len(match.Arms); i++ {
+		if variant, isVariant := match.Arms[i].Pattern.(parser.VariantPattern); isVariant {
+			en, ok = variantEnum(s.lookup(variant.Name.Identifier.Literal))
+		}
+	}
+
+	if !ok {
+		return
+	}
+
+	covered := make(map[string]bool)
+	for _, arm := range match.Arms {
+		if arm.Guard == nil && covers(arm.Pattern, en, covered) {
+			return
+		}
+	}
+
+	var missing []string
+	for _, variant := range en.Variants {
+		if !covered[variant.Name] {
+			missing = append(missing, variant.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		c.warn(fmt.Sprintf("match is not exhaustive, missing %s of %s", strings.Join(missing, ", "), en), match)
+	}
+}
+
+// covers marks variants of the enum matched by the pattern regardless of their fields, true is returned
+// if the pattern matches any value
+func covers(pattern parser.Pattern, en *Enum, covered map[string]bool) bool {
+	switch p := pattern.(type) {
+	case parser.IdentifierExpression, parser.WildcardPattern:
+		return true
+	case parser.VariantPattern:
+		if _, ok := en.Variant(p.Name.Identifier.Literal); !ok {
+			return false
+		}
+
+		for _, field := range p.Fields {
+			switch field.(type) {
+			case parser.IdentifierExpression, parser.WildcardPattern:
+			default:
+				return false
+			}
+		}
+
+		covered[p.Name.Identifier.Literal] = true
+	case parser.AlternativePattern:
+		for _, alternative := range p.Alternatives {
+			if covers(alternative, en, covered) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// join returns the type of the elements of the collection, a union if elements have different types
+func join(types []Type) Type {
+	if len(types) == 0 {
+		return Any
+	}
+
+	seen := make(map[string]bool)
+	union := Union{}
+	for _, t := range types {
+		if t == Any {
+			return Any
+		}
+
+		if !seen[t.String()] {
+			seen[t.String()] = true
+			union.Types = append(union.Types, t)
+		}
+	}
+
+	if len(union.Types) == 1 {
+		return union.Types[0]
+	}
+
+	return union
+}
+
+func isNumeric(t Type) bool {
+	return t == Int || t == Float || t == Bool
+}
+
+func isNumber(t Type) bool {
+	return t == Int || t == Float
+}
+
+func isEnum(t Type) bool {
+	_, ok := t.(*Enum)
+	return ok
+}
+
+func isFunc(t Type) bool {
+	_, ok := t.(Func)
+	return ok
+}
+
+func isArray(t Type) bool {
+	_, ok := t.(Array)
+	return ok
+}
+
+func isUnion(t Type) bool {
+	_, ok := t.(Union)
+	return ok
+}
+
+var comparisons = map[string]bool{
+	"==": true,
+	"!=": true,
+	"<":  true,
+	">":  true,
+	"<=": true,
+	">=": true,
+	"&&": true,
+	"||": true,
+}
+
+func (c *Checker) prefix(prefix parser.PrefixExpression, operand Type) Type {
+	switch prefix.Prefix.Literal {
+	case "!":
+		return Bool
+	case "-":
+		if operand == Int || operand == Float {
+			return operand
+		}
+	case "~":
+		if operand == Int {
+			return Int
+		}
+	}
+
+	if _, ok := operand.(Basic); ok && operand != Any && operand != Bool {
+		c.report(fmt.Sprintf("operator %s is not defined for %s", prefix.Prefix.Literal, operand), prefix)
+	}
+
+	return Any
+}
+
+// infix mirrors the operand types supported by the evaluator: numbers and bools with each other, strings
+// with strings and numbers, ranges of integers. Operands of any and union types are not checked.
+func (c *Checker) infix(infix *parser.InfixExpression, left, right Type) Type {
+	operator := infix.Operator.Literal
+	switch {
+	case operator == ".." || operator == "..=":
+		if !AssignableTo(left, Int) || !AssignableTo(right, Int) {
+			c.report(fmt.Sprintf("operator %s is not defined for %s and %s", operator, left, right), infix)
+		}
+
+		return Range
+	case left == Any || right == Any || isUnion(left) || isUnion(right):
+		if comparisons[operator] {
+			return Bool
+		}
+
+		return Any
+	case isEnum(left) && isEnum(right) && (operator == "==" || operator == "!="):
+		return Bool
+	case isFunc(left) && isFunc(right) && operator == ">>":
+		return Func{Params: left.(Func).Params, Result: right.(Func).Result}
+	case left == String && (right == String || isNumber(right)), right == String && isNumber(left):
+		return String
+	case isNumeric(left) && isNumeric(right):
+		switch {
+		case comparisons[operator]:
+			return Bool
+		case left == Float || right == Float:
+			return Float
+		case operator == "**":
+			// integer power with negative exponent is float
+			return Union{Types: []Type{Int, Float}}
+		default:
+			return Int
+		}
+	}
+
+	c.report(fmt.Sprintf("operator %s is not defined for %s and %s", operator, left, right), infix)
+	return Any
+}
+
+func (c *Checker) function(fn parser.FuncExpression, s *scope) Type {
+	t := Func{
+		Params: make([]Param, 0, len(fn.Args)),
+		Result: c.annotation(fn.ReturnType, s),
+	}
+
+	body := newScope(s)
+	for _, arg := range fn.Args {
+		param := Param{
+			Type:     c.annotation(arg.Type, body),
+			Optional: arg.Default != nil || arg.Rest,
+			Rest:     arg.Rest,
+		}
+
+		param.Name, _ = arg.Name()
+		if arg.Rest && arg.Type == nil {
+			param.Type = Array{Elem: Any}
+		}
+
+		if arg.Default != nil {
+			if val := c.expression(arg.Default, body); !AssignableTo(val, param.Type) {
+				c.report(fmt.Sprintf("can not use %s as default value of %s of type %s", val, arg.Pattern, param.Type), arg.Default)
+			}
+		}
+
+		c.bind(body, arg.Pattern, param.Type)
+		t.Params = append(t.Params, param)
+	}
+
+	result := c.result
+	c.result = t.Result
+	defer func() { c.result = result }()
+	if fn.Generator {
+		// generators return the iterator, values of return statements are ignored
+		t.Result = Any
+		c.result = Any
+		c.statements(fn.Body.Statements, body)
+		return t
+	}
+
+	// the value of the last expression statement is the result of the function
+	stmts := fn.Body.Statements
+	if len(stmts) == 0 {
+		if !AssignableTo(Nil, t.Result) {
+			c.report(fmt.Sprintf("can not return nil from function returning %s", t.Result), fn)
+		}
+
+		return t
+	}
+
+	c.statements(stmts[:len(stmts)-1], body)
+	last, ok := stmts[len(stmts)-1].(parser.ExpressionStatement)
+	if !ok {
+		c.statement(stmts[len(stmts)-1], body)
+		if _, returns := stmts[len(stmts)-1].(parser.ReturnStatement); !returns && !AssignableTo(Nil, t.Result) {
+			c.report(fmt.Sprintf("can not return nil from function returning %s", t.Result), fn)
+		}
+
+		return t
+	}
+
+	if val := c.expression(last.Expr, body); !AssignableTo(val, t.Result) {
+		c.report(fmt.Sprintf("can not return %s from function returning %s", val, t.Result), last.Expr)
+	}
+
+	return t
+}
+
+func (c *Checker) call(call parser.CallExpression, s *scope) Type {
+	if member, ok := call.Call.(parser.MemberExpression); ok {
+		return c.methodCall(call, member, s)
+	}
+
+	return c.apply(call, c.expression(call.Call, s), s)
+}
+
+// methodCall checks calls of the fields and methods of structs, build in methods and functions called
+// with the receiver as the first argument are not checked
+func (c *Checker) methodCall(call parser.CallExpression, member parser.MemberExpression, s *scope) Type {
+	receiver := c.expression(member.Of, s)
+	name := member.Field.Identifier.Literal
+	if st, ok := receiver.(*Struct); ok {
+		if field, ok := st.Field(name); ok {
+			return c.apply(call, field, s)
+		}
+
+		if method, ok := st.Methods[name]; ok {
+			return c.apply(call, method, s)
+		}
+	}
+
+	for _, arg := range call.CallArgs {
+		c.expression(arg, s)
+	}
+
+	return Any
+}
+
+func (c *Checker) apply(call parser.CallExpression, callee Type, s *scope) Type {
+	args := make([]Type, 0, len(call.CallArgs))
+	spread := false
+	for _, arg := range call.CallArgs {
+		args = append(args, c.expression(arg, s))
+		_, isSpread := arg.(parser.SpreadExpression)
+		spread = spread || isSpread
+	}
+
+	fn, ok := callee.(Func)
+	if !ok {
+		if callee != Any {
+			c.report(fmt.Sprintf("can not call %s", callee), call)
+		}
+
+		return Any
+	}
+
+	if fn.Params == nil {
+		return fn.Result
+	}
+
+	given := make(map[int]bool)
+	position := 0
+	for i, arg := range call.CallArgs {
+		switch a := arg.(type) {
+		case parser.SpreadExpression:
+			// positions of the following arguments are unknown
+			position = -1
+		case parser.NamedArgument:
+			for j, param := range fn.Params {
+				if param.Name == a.Name.Identifier.Literal && !param.Rest {
+					given[j] = true
+					c.argument(param, args[i], arg)
+				}
+			}
+		default:
+			if position < 0 {
+				continue
+			}
+
+			switch {
+			case position < len(fn.Params) && !fn.Params[position].Rest:
+				given[position] = true
+				c.argument(fn.Params[position], args[i], arg)
+				position++
+			case len(fn.Params) > 0 && fn.Params[len(fn.Params)-1].Rest:
+				rest := fn.Params[len(fn.Params)-1]
+				if arr, ok := rest.Type.(Array); ok {
+					c.argument(Param{Name: rest.Name, Type: arr.Elem}, args[i], arg)
+				}
+			default:
+				c.report(fmt.Sprintf("unexpected argument, function accepts %d arguments", len(fn.Params)), arg)
+			}
+		}
+	}
+
+	if !spread {
+		for i, param := range fn.Params {
+			if !given[i] && !param.Optional {
+				c.report(fmt.Sprintf("missing argument for parameter %s", param.Name), call)
+			}
+		}
+	}
+
+	return fn.Result
+}
+
+func (c *Checker) argument(param Param, arg Type, node parser.Node) {
+	if !AssignableTo(arg, param.Type) {
+		c.report(fmt.Sprintf("can not use %s as %s in argument %s", arg, param.Type, param.Name), node)
+	}
+}
+
+func (c *Checker) assign(assign parser.AssignExpression, s *scope) Type {
+	target := c.expression(assign.Identifier, s)
+	val := c.expression(assign.Val, s)
+	if operator, ok := assign.Operator(); ok {
+		val = c.infix(&parser.InfixExpression{Left: assign.Identifier, Operator: operator, Right: assign.Val}, target, val)
+	}
+
+	if !AssignableTo(val, target) {
+		c.report(fmt.Sprintf("can not assign %s to %s of type %s", val, assign.Identifier, target), assign.Val)
+	}
+
+	return val
+}
+
+func (c *Checker) index(index parser.IndexExpression, s *scope) Type {
+	of := c.expression(index.Of, s)
+	idx := c.expression(index.Idx, s)
+	switch t := of.(type) {
+	case Array:
+		if !AssignableTo(idx, Int) {
+			c.report(fmt.Sprintf("array index must be int, got %s", idx), index.Idx)
+		}
+
+		return t.Elem
+	case Map:
+		if !AssignableTo(idx, t.Key) {
+			c.report(fmt.Sprintf("can not use %s as key of %s", idx, t), index.Idx)
+		}
+
+		return t.Val
+	}
+
+	switch of {
+	case String:
+		return String
+	case Range:
+		if !AssignableTo(idx, Int) {
+			c.report(fmt.Sprintf("range index must be int, got %s", idx), index.Idx)
+		}
+
+		return Int
+	}
+
+	return Any
+}
+
+// member mirrors the field access of the evaluator, fields of maps are string keys
+func (c *Checker) member(member parser.MemberExpression, s *scope) Type {
+	of := c.expression(member.Of, s)
+	name := member.Field.Identifier.Literal
+	switch t := of.(type) {
+	case *Struct:
+		field, ok := t.Field(name)
+		if !ok {
+			c.report(fmt.Sprintf("struct %s has no field %s", t, name), member.Field)
+			return Any
+		}
+
+		return field
+	case Map:
+		if !AssignableTo(String, t.Key) {
+			c.report(fmt.Sprintf("can not use string as key of %s", t), member.Field)
+		}
+
+		return t.Val
+	case Array:
+		c.report(fmt.Sprintf("can not access field %s of %s", name, of), member.Field)
+	case Basic:
+		if of == Error && name == "message" {
+			return String
+		}
+
+		if of != Any {
+			c.report(fmt.Sprintf("can not access field %s of %s", name, of), member.Field)
+		}
+	}
+
+	return Any
+}
+
+func (c *Checker) structLiteral(literal parser.StructExpression, s *scope) Type {
+	fields := make(map[string]Type, len(literal.Fields))
+	for _, field := range literal.Fields {
+		fields[field.Name.Identifier.Literal] = c.expression(field.Val, s)
+	}
+
+	constructor, ok := c.expression(literal.Name, s).(Func)
+	if !ok {
+		return Any
+	}
+
+	st, ok := constructor.Result.(*Struct)
+	if !ok {
+		return Any
+	}
+
+	for _, field := range literal.Fields {
+		name := field.Name.Identifier.Literal
+		t, ok := st.Field(name)
+		if !ok {
+			c.report(fmt.Sprintf("struct %s has no field %s", st, name), field)
+			continue
+		}
+
+		if !AssignableTo(fields[name], t) {
+			c.report(fmt.Sprintf("can not use %s as %s in field %s", fields[name], t, name), field.Val)
+		}
+	}
+
+	for _, field := range st.Fields {
+		if _, ok := fields[field.Name]; !ok {
+			c.report(fmt.Sprintf("missing field %s of struct %s", field.Name, st), literal)
+		}
+	}
+
+	return st
+}
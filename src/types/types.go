@@ -0,0 +1,408 @@
+package types
+
+import (
+	"fmt"
+	"github.com/charkpep/yami/src/object"
+	"github.com/charkpep/yami/src/parser"
+	"strings"
+)
+
+// Type static type of the value, Any is compatible with every type, so unannotated code is not checked
+type Type interface {
+	String() string
+}
+
+// Basic types without parameters: int, float, string, bool, nil, fn and any
+type Basic struct {
+	Name string
+}
+
+func (b Basic) String() string {
+	return b.Name
+}
+
+var (
+	Any    = Basic{Name: "any"}
+	Int    = Basic{Name: "int"}
+	Float  = Basic{Name: "float"}
+	String = Basic{Name: "string"}
+	Bool   = Basic{Name: "bool"}
+	Nil    = Basic{Name: "nil"}
+	Range  = Basic{Name: "range"}
+	Chan   = Basic{Name: "channel"}
+	Task   = Basic{Name: "task"}
+	Error  = Basic{Name: "error"}
+)
+
+var basics = map[string]Type{
+	"any":     Any,
+	"int":     Int,
+	"float":   Float,
+	"string":  String,
+	"bool":    Bool,
+	"nil":     Nil,
+	"range":   Range,
+	"channel": Chan,
+	"task":    Task,
+	"error":   Error,
+}
+
+type Array struct {
+	Elem Type
+}
+
+func (a Array) String() string {
+	return fmt.Sprintf("array<%s>", a.Elem)
+}
+
+type Map struct {
+	Key Type
+	Val Type
+}
+
+func (m Map) String() string {
+	return fmt.Sprintf("map<%s, %s>", m.Key, m.Val)
+}
+
+type Union struct {
+	Types []Type
+}
+
+func (u Union) String() string {
+	types := make([]string, 0, len(u.Types))
+	for _, t := range u.Types {
+		types = append(types, t.String())
+	}
+
+	return strings.Join(types, " | ")
+}
+
+// Func type of the function, the fn annotation is a function with unknown parameters (Params is nil)
+type Func struct {
+	Params []Param
+	Result Type
+}
+
+// Param parameter of the function type, parameters with default values and the rest parameter are optional
+type Param struct {
+	Name     string
+	Type     Type
+	Optional bool
+	Rest     bool
+}
+
+func (f Func) String() string {
+	if f.Params == nil {
+		return "fn"
+	}
+
+	params := make([]string, 0, len(f.Params))
+	for _, param := range f.Params {
+		params = append(params, param.Type.String())
+	}
+
+	return fmt.Sprintf("fn(%s) -> %s", strings.Join(params, ", "), f.Result)
+}
+
+// Struct type declared by struct Name { ... }, structs are compared by name. Methods do not include
+// the receiver parameter.
+type Struct struct {
+	Name    string
+	Fields  []Param
+	Methods map[string]Func
+}
+
+func (s *Struct) String() string {
+	return s.Name
+}
+
+// Field returns the type of the field, false if the struct has no such field
+func (s *Struct) Field(name string) (Type, bool) {
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field.Type, true
+		}
+	}
+
+	return nil, false
+}
+
+// Enum type declared by enum Name { ... }, enums are compared by name
+type Enum struct {
+	Name     string
+	Variants []Variant
+}
+
+// Variant of the enum type, variants without fields have nil Fields
+type Variant struct {
+	Name   string
+	Fields []Param
+}
+
+func (e *Enum) String() string {
+	return e.Name
+}
+
+// Variant returns the variant by name, false if the enum has no such variant
+func (e *Enum) Variant(name string) (Variant, bool) {
+	for _, variant := range e.Variants {
+		if variant.Name == name {
+			return variant, true
+		}
+	}
+
+	return Variant{}, false
+}
+
+// Named user defined type known only by its name, values of structs and enums are compared with the name
+// of their declaration
+type Named struct {
+	Name string
+}
+
+func (n Named) String() string {
+	return n.Name
+}
+
+// Lookup resolves names of the user defined types, e.g. structs, false if the name is unknown
+type Lookup func(name string) (Type, bool)
+
+// FromAnnotation converts the type annotation to the type, nil annotation is Any
+func FromAnnotation(annotation parser.TypeAnnotation) (Type, error) {
+	return ResolveAnnotation(annotation, nil)
+}
+
+// ResolveAnnotation converts the type annotation to the type, names which are not build in types are resolved
+// with lookup
+func ResolveAnnotation(annotation parser.TypeAnnotation, lookup Lookup) (Type, error) {
+	switch a := annotation.(type) {
+	case nil:
+		return Any, nil
+	case parser.NamedType:
+		switch a.Name {
+		case "fn":
+			return Func{Result: Any}, nil
+		case "array":
+			return Array{Elem: Any}, nil
+		case "map":
+			return Map{Key: Any, Val: Any}, nil
+		}
+
+		if t, ok := basics[a.Name]; ok {
+			return t, nil
+		}
+
+		if lookup != nil {
+			if t, ok := lookup(a.Name); ok {
+				return t, nil
+			}
+		}
+
+		return nil, fmt.Errorf("unknown type %s", a.Name)
+	case parser.GenericType:
+		params := make([]Type, 0, len(a.Params))
+		for _, param := range a.Params {
+			t, err := ResolveAnnotation(param, lookup)
+			if err != nil {
+				return nil, err
+			}
+
+			params = append(params, t)
+		}
+
+		switch {
+		case a.Name == "array" && len(params) == 1:
+			return Array{Elem: params[0]}, nil
+		case a.Name == "map" && len(params) == 2:
+			return Map{Key: params[0], Val: params[1]}, nil
+		case a.Name == "array":
+			return nil, fmt.Errorf("array expects 1 type parameter, got %d", len(params))
+		case a.Name == "map":
+			return nil, fmt.Errorf("map expects 2 type parameters, got %d", len(params))
+		}
+
+		return nil, fmt.Errorf("type %s does not accept type parameters", a.Name)
+	case parser.UnionType:
+		union := Union{}
+		for _, annotation := range a.Types {
+			t, err := ResolveAnnotation(annotation, lookup)
+			if err != nil {
+				return nil, err
+			}
+
+			union.Types = append(union.Types, t)
+		}
+
+		return union, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type annotation %s", annotation)
+}
+
+// AssignableTo reports whether values of the type src can be used where dst is expected. Any is compatible
+// in both directions, int is assignable to float.
+func AssignableTo(src, dst Type) bool {
+	if src == Any || dst == Any {
+		return true
+	}
+
+	if u, ok := src.(Union); ok {
+		for _, t := range u.Types {
+			if !AssignableTo(t, dst) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	switch d := dst.(type) {
+	case Union:
+		for _, t := range d.Types {
+			if AssignableTo(src, t) {
+				return true
+			}
+		}
+
+		return false
+	case Basic:
+		return src == dst || (src == Int && dst == Float)
+	case Array:
+		s, ok := src.(Array)
+		return ok && AssignableTo(s.Elem, d.Elem)
+	case Map:
+		s, ok := src.(Map)
+		return ok && AssignableTo(s.Key, d.Key) && AssignableTo(s.Val, d.Val)
+	case Func:
+		_, ok := src.(Func)
+		return ok
+	case *Struct:
+		s, ok := src.(*Struct)
+		return ok && s.Name == d.Name
+	case *Enum:
+		s, ok := src.(*Enum)
+		return ok && s.Name == d.Name
+	case Named:
+		return src.String() == d.Name
+	}
+
+	return false
+}
+
+// Conforms reports whether the runtime value has the type, elements of arrays and maps are checked as well
+func Conforms(val object.Object, t Type) bool {
+	switch t := t.(type) {
+	case Union:
+		for _, member := range t.Types {
+			if Conforms(val, member) {
+				return true
+			}
+		}
+
+		return false
+	case Array:
+		arr, ok := val.(*object.ArrayObject)
+		if !ok {
+			return false
+		}
+
+		for _, elem := range arr.Val {
+			if !Conforms(elem, t.Elem) {
+				return false
+			}
+		}
+
+		return true
+	case Map:
+		mp, ok := val.(*object.MapObject)
+		if !ok {
+			return false
+		}
+
+		for k, v := range mp.Val {
+			if !Conforms(k, t.Key) || !Conforms(v, t.Val) {
+				return false
+			}
+		}
+
+		return true
+	case Func:
+		return object.IsCallable(val)
+	case *Struct:
+		st, ok := val.(*object.StructObject)
+		return ok && st.Def.Name == t.Name
+	case *Enum:
+		en, ok := val.(*object.EnumObject)
+		return ok && en.Variant.Enum.Name == t.Name
+	case Named:
+		switch v := val.(type) {
+		case *object.StructObject:
+			return v.Def.Name == t.Name
+		case *object.EnumObject:
+			return v.Variant.Enum.Name == t.Name
+		}
+
+		return false
+	}
+
+	switch t {
+	case Any:
+		return true
+	case Int:
+		return val.Type() == object.INTEGER_OBJ || val.Type() == object.BIGINT_OBJ
+	case Float:
+		return object.IsNumber(val)
+	case String:
+		return val.Type() == object.STRING_OBJ
+	case Bool:
+		return val.Type() == object.BOOL_OBJ
+	case Nil:
+		return val.Type() == object.NIL_OBJ
+	case Range:
+		return val.Type() == object.RANGE_OBJ
+	case Chan:
+		return val.Type() == object.CHANNEL_OBJ
+	case Task:
+		return val.Type() == object.TASK_OBJ
+	case Error:
+		return val.Type() == object.ERROR_OBJ
+	}
+
+	return false
+}
+
+// Of returns the type of the runtime value, elements of arrays and maps are not inspected
+func Of(val object.Object) Type {
+	switch val.Type() {
+	case object.INTEGER_OBJ, object.BIGINT_OBJ:
+		return Int
+	case object.FLOAT_OBJ:
+		return Float
+	case object.STRING_OBJ:
+		return String
+	case object.BOOL_OBJ:
+		return Bool
+	case object.NIL_OBJ:
+		return Nil
+	case object.RANGE_OBJ:
+		return Range
+	case object.CHANNEL_OBJ:
+		return Chan
+	case object.TASK_OBJ:
+		return Task
+	case object.ERROR_OBJ:
+		return Error
+	case object.ARRAY_OBJ:
+		return Array{Elem: Any}
+	case object.MAP_OBJ:
+		return Map{Key: Any, Val: Any}
+	case object.FUNC_OBJ, object.BUILDIN_OBJ, object.STRUCT_TYPE_OBJ, object.VARIANT_OBJ, object.COMPOSED_OBJ:
+		return Func{Result: Any}
+	case object.STRUCT_OBJ:
+		return &Struct{Name: val.(*object.StructObject).Def.Name}
+	case object.ENUM_OBJ:
+		return &Enum{Name: val.(*object.EnumObject).Variant.Enum.Name}
+	}
+
+	return Any
+}
@@ -0,0 +1,144 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/charkpep/yami/src/parser"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	type tt struct {
+		in          string
+		diagnostics []string
+	}
+
+	ts := []tt{
+		{"let x = 1; x = \"a\"", nil},
+		{"let n: int = 10; n = 5", nil},
+		{"let f: float = 1", nil},
+		{"let v: int | nil = nil; v = 1", nil},
+		{"let xs: array<int> = [1, 2]; let first: int = xs[0]", nil},
+		{"let m: map<string, array<int>> = {\"a\": [1]}; m[\"b\"] = [2]", nil},
+		{"let f = fn(x: int, y: string = \"a\") -> bool { x > 1 }; f(1); f(1, y: \"b\")", nil},
+		{"let f = fn(x: int) -> int { if x > 0 { return x }; 0 }; let r: int = f(1)", nil},
+		{"let f = fn(...xs: array<int>) { xs }; f(1, 2, ...[3])", nil},
+		{"let any = fn(x) { x }; let n: int = any(\"a\")", nil},
+		{"let s: string = \"a\" + 1", nil},
+		{"let n: int = \"a\"", []string{"can not assign string to n of type int"}},
+		{"let n: int = 1; n = nil", []string{"can not assign nil to n of type int"}},
+		{"let n: int = 1; n += 1.5", []string{"can not assign float to n of type int"}},
+		{"let xs: array<int> = [1, \"a\"]", []string{"can not assign array<int | string> to xs of type array<int>"}},
+		{"let xs: array<int> = [1]; xs[\"a\"]", []string{"array index must be int, got string"}},
+		{"let m: map<string, int> = {}; m[\"a\"] = \"b\"", []string{"can not assign string to m[\"a\"] of type int"}},
+		{"let x: integer = 1", []string{"unknown type integer"}},
+		{"let x: array<int, int> = []", []string{"array expects 1 type parameter, got 2"}},
+		{"let f = fn(x: int) { x }; f(\"a\")", []string{"can not use string as int in argument x"}},
+		{"let f = fn(x: int) { x }; f(x: \"a\")", []string{"can not use string as int in argument x"}},
+		{"let f = fn(x: int, y: int) { x }; f(1)", []string{"missing argument for parameter y"}},
+		{"let f = fn(x: int) { x }; f(1, 2)", []string{"unexpected argument, function accepts 1 arguments"}},
+		{"let f = fn(...xs: array<int>) { xs }; f(1, \"a\")", []string{"can not use string as int in argument xs"}},
+		{"let f = fn(x: int = \"a\") { x }", []string{"can not use string as default value of x of type int"}},
+		{"let f = fn() -> int { \"a\" }", []string{"can not return string from function returning int"}},
+		{"let f = fn() -> int { return nil }", []string{"can not return nil from function returning int"}},
+		{"let f = fn() -> int {}", []string{"can not return nil from function returning int"}},
+		{"let f = fn() -> bool { true }; let n: int = f()", []string{"can not assign bool to n of type int"}},
+		{"let s: string = \"a\"; s - [1]", []string{"operator - is not defined for string and array<int>"}},
+		{"-\"a\"", []string{"operator - is not defined for string"}},
+		{"const n = 1; n()", []string{"can not call int"}},
+		{"struct P { x: int, y }; let p: P = P(1, \"a\"); let n: int = p.x; p.y = nil", nil},
+		{"struct Node { val: int, next: Node | nil }; const n = Node{val: 1, next: Node(2, nil)}; let v: Node | nil = n.next", nil},
+		{"const m = {\"a\": 1}; let n: int = m.a", nil},
+		{"struct P { x: int }; const p = P{x: 1}; p.x = \"a\"", []string{"can not assign string to p.x of type int"}},
+		{"struct P { x: int }; P(\"a\")", []string{"can not use string as int in argument x"}},
+		{"struct P { x: int }; P{x: \"a\"}", []string{"can not use string as int in field x"}},
+		{"struct P { x }; P{}", []string{"missing field x of struct P"}},
+		{"struct P { x }; P{y: 1, x: 1}", []string{"struct P has no field y"}},
+		{"struct P { x }; const p = P(1); p.y", []string{"struct P has no field y"}},
+		{"struct P { x }; struct Q { x }; let p: P = Q(1)", []string{"can not assign Q to p of type P"}},
+		{"let f = fn(p: Point) { p }", []string{"unknown type Point"}},
+		{"const n = 1; n.x", []string{"can not access field x of int"}},
+		{"const s = \"a\"; let n: int = s.upper()", nil},
+		{"struct P { x: int, fn get(self) -> int { self.x } }; const p = P(1); let s: string = p.get()", []string{"can not assign int to s of type string"}},
+		{"struct P { x, fn scale(self, k: int) { self.x * k } }; const p = P(1); p.scale(\"a\")", []string{"can not use string as int in argument k"}},
+		{"struct P { f: int }; const p = P(1); p.f()", []string{"can not call int"}},
+		{"enum Option { Some(v: int), None }; let o: Option = Some(1); o = None; let b: bool = o == None", nil},
+		{"enum R { Ok(v), Err(m) }; let f = fn(r) { match r { Ok(v) => v, Err(m) => m } }", nil},
+		{"enum R { Ok(v), Err(m) }; let f = fn(r) { match r { Ok(1) => 1, _ => 0 } }", nil},
+		{"enum R { Ok(v), Err(m) }; let f = fn(r) { match r { Ok(v) | Err(v) => v } }", nil},
+		{"enum Option { Some(v: int), None }; Some(\"a\")", []string{"can not use string as int in argument v"}},
+		{"enum Option { Some(v: int), None }; enum R { Ok(v) }; let o: Option = Ok(1)", []string{"can not assign R to o of type Option"}},
+		{"enum Option { Some(v: int), None }; let f = fn(o) { match o { Some(v) => { let s: string = v } None => 0 } }", []string{"can not assign int to s of type string"}},
+		{"enum R { Ok(v), Err(m), None }; let f = fn(r) { match r { Ok(v) => v } }", []string{"Warning | line: 0, column: 53 | message: match is not exhaustive, missing Err, None of R"}},
+		{"enum R { Ok(v), Err(m) }; let f = fn(r) { match r { Ok(v) if v > 0 => v, Err(_) => 0 } }", []string{"missing Ok of R"}},
+		{"enum R { Ok(v), Err(m) }; let f = fn(r: R) { match r { Ok(1) => 1, Err(m) => 0 } }", []string{"missing Ok of R"}},
+		{"let gen = fn(n: int) -> int { yield n; return \"a\" }; gen(1).to_array()", nil},
+		{"const xs: array<int> = [1]; for x in xs { let s: string = x }", []string{"can not assign int to s of type string"}},
+		{"for c in \"ab\" { let n: int = c }", []string{"can not assign string to n of type int"}},
+		{"for i in 0..3 { let s: string = i }", []string{"can not assign int to s of type string"}},
+		{"let r: range = 0..=3; let n: int = r[1]; let s: string = r", []string{"can not assign range to s of type string"}},
+		{"0..\"a\"", []string{"operator .. is not defined for int and string"}},
+		{"let f = fn(a: int) -> int { a }; \"s\" |> f", []string{"can not use string as int in argument a"}},
+		{"let f = fn(a: int, b: int) -> int { a }; let s: string = 1 |> f(2)", []string{"can not assign int to s of type string"}},
+		{"const f = |x: int| x * 2; f(\"a\")", []string{"can not use string as int in argument x"}},
+		{"let f = fn(x) -> int { let y = x }", []string{"can not return nil from function returning int"}},
+		{"let f = fn(x) -> int { return x }", nil},
+		{"let f = fn(x: int) -> int { x }; let t: task = spawn f(\"a\"); await 1", []string{"can not use string as int in argument x", "can not await int"}},
+		{"let ch: channel = channel(1); select { x in ch => x, v in 1 => v }", []string{"expected channel, got int"}},
+		{"let f = fn(e: error) -> string { e.message }; let g = fn(e: error) -> int { e.message }; let h = fn(e: error) { e.code }", []string{"can not return string from function returning int", "can not access field code of error"}},
+		{"let f = fn(a: int) -> string { \"\" }; let g = fn(s: string) -> int { 1 }; const h = f >> g; let s: string = h(1)", []string{"can not assign int to s of type string"}},
+	}
+
+	for i, test := range ts {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			p := parser.NewParser(bytes.NewBufferString(test.in))
+			root, err := p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(p.Errors) != 0 {
+				t.Fatal(p.Errors)
+			}
+
+			diagnostics := Check(root)
+			if len(diagnostics) != len(test.diagnostics) {
+				t.Fatalf("expected %v, got %v", test.diagnostics, diagnostics)
+			}
+
+			for j, d := range diagnostics {
+				if !strings.Contains(d.Error(), test.diagnostics[j]) {
+					t.Errorf("expected %q, got %q", test.diagnostics[j], d.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestAssignableTo(t *testing.T) {
+	type tt struct {
+		src, dst Type
+		ok       bool
+	}
+
+	ts := []tt{
+		{Int, Any, true},
+		{Any, Int, true},
+		{Int, Float, true},
+		{Float, Int, false},
+		{Nil, Union{Types: []Type{Int, Nil}}, true},
+		{Union{Types: []Type{Int, Nil}}, Int, false},
+		{Array{Elem: Int}, Array{Elem: Union{Types: []Type{Int, Nil}}}, true},
+		{Map{Key: String, Val: Int}, Map{Key: String, Val: String}, false},
+		{Func{Params: []Param{}, Result: Int}, Func{Result: Any}, true},
+	}
+
+	for i, test := range ts {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			if AssignableTo(test.src, test.dst) != test.ok {
+				t.Errorf("expected AssignableTo(%s, %s) to be %v", test.src, test.dst, test.ok)
+			}
+		})
+	}
+}